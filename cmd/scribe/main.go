@@ -1,17 +1,26 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/felipevolpatto/scribe/internal/bump"
+	"github.com/felipevolpatto/scribe/internal/changelog"
 	cfg "github.com/felipevolpatto/scribe/internal/config"
+	"github.com/felipevolpatto/scribe/internal/export"
+	"github.com/felipevolpatto/scribe/internal/forge"
 	gitpkg "github.com/felipevolpatto/scribe/internal/git"
+	"github.com/felipevolpatto/scribe/internal/lint"
 	md "github.com/felipevolpatto/scribe/internal/markdown"
 	"github.com/felipevolpatto/scribe/internal/parser"
+	"github.com/felipevolpatto/scribe/internal/semver"
 	"github.com/felipevolpatto/scribe/internal/tui"
 	wf "github.com/felipevolpatto/scribe/internal/workflow"
 )
@@ -21,6 +30,11 @@ func main() {
 
     var repoPath string
     var fromRef string
+    var toRef string
+    var newPackage string
+    var format string
+    var newNoInteractive bool
+    var newFailOnSkipped bool
 
     newCmd := &cobra.Command{
         Use:   "new",
@@ -30,40 +44,77 @@ func main() {
             if err != nil {
                 return err
             }
+            t, err := resolveTarget(repoPath, configuration, newPackage)
+            if err != nil {
+                return err
+            }
 
             ref := fromRef
-            if ref == "" {
-                tag, err := gitpkg.GetLatestTag(repoPath)
+            if ref == "" && toRef != "" {
+                ref, err = previousTag(repoPath, toRef, t)
+                if err != nil {
+                    return err
+                }
+            } else if ref == "" {
+                tag, err := gitpkg.GetLatestTag(repoPath, t.tagPrefix, t.tagPattern)
                 if err == nil {
                     ref = tag
                 }
             }
 
-            commits, err := gitpkg.GetCommitsSince(repoPath, ref)
+            parsedCommits, skipped, err := collectCommits(repoPath, ref, toRef, t, configuration)
             if err != nil {
                 return err
             }
 
-            var parsedCommits []*parser.ParsedCommit
-            for i := range commits {
-                msg := strings.Split(commits[i].Message, "\n")[0]
-                pc, err := parser.Parse(msg)
+            curated := parsedCommits
+            if !newNoInteractive {
+                curated, skipped, err = tui.Run(parsedCommits, skipped, configuration)
                 if err != nil {
-                    continue
-                }
-                if shouldIgnoreByScope(pc.Scope, configuration) {
-                    continue
+                    return err
                 }
-                pc.Raw = &commits[i]
-                parsedCommits = append(parsedCommits, pc)
+            }
+            if err := reportSkipped(os.Stderr, skipped, newFailOnSkipped); err != nil {
+                return err
             }
 
-            curated, err := tui.Run(parsedCommits, configuration)
+            // A range ending at a release tag regenerates that release.
+            version, date := "Unreleased", time.Time{}
+            if toRef != "" {
+                tag, err := releaseTag(repoPath, toRef, t)
+                if err != nil {
+                    return err
+                }
+                if tag != nil {
+                    version, date = versionWithV(strings.TrimPrefix(tag.Name, t.tagPrefix)), tag.Date
+                }
+            }
+            cl := changelog.Build(version, ref, date, curated, configuration)
+            known, err := knownContributors(repoPath, ref, t, configuration)
             if err != nil {
                 return err
             }
-
-            out, err := md.Render("Unreleased", curated, configuration)
+            cl.FindNewContributors(known)
+            end := toRef
+            if end == "" {
+                end = "HEAD"
+            }
+            cl.Link(t.forge, end)
+            var out string
+            switch format {
+            case "markdown", "md":
+                out, err = md.Render(cl, configuration)
+            case "json":
+                var b []byte
+                b, err = export.JSON(cl)
+                out = string(b)
+            case "yaml", "yml":
+                var b []byte
+                b, err = export.YAML(cl)
+                out = strings.TrimSuffix(string(b), "\n")
+            default:
+                return fmt.Errorf("unknown format %q (expected markdown, json or yaml)", format)
+            }
             if err != nil {
                 return err
             }
@@ -72,70 +123,348 @@ func main() {
         },
     }
     newCmd.Flags().StringVar(&repoPath, "path", ".", "Path to the git repository")
-    newCmd.Flags().StringVar(&fromRef, "from-ref", "", "Git ref to start from instead of the latest tag")
+    newCmd.Flags().StringVar(&fromRef, "from-ref", "", "Git ref to start from instead of the latest tag (tag, branch, commit SHA, HEAD~n)")
+    newCmd.Flags().StringVar(&toRef, "to-ref", "", "Git ref to end at instead of HEAD; defaults --from-ref to the previous tag")
+    newCmd.Flags().StringVar(&format, "format", "markdown", "Output format: markdown, json or yaml")
+    newCmd.Flags().BoolVar(&newNoInteractive, "no-interactive", false, "Disable interactive TUI")
+    newCmd.Flags().BoolVar(&newFailOnSkipped, "fail-on-skipped", false, "Fail when commits are skipped because they are not Conventional Commits")
+    newCmd.Flags().StringVar(&newPackage, "package", "", "Monorepo package from .scribe.yml to generate the changelog for")
 
     var releaseRepoPath string
     var noInteractive bool
+    var bumpMode string
+    var releasePackage string
+    var dryRun bool
+    var annotate bool
+    var sign bool
+    var signingKey string
+    var push bool
+    var releaseForce bool
+    var releaseFailOnSkipped bool
+    var remote string
 
     releaseCmd := &cobra.Command{
-        Use:   "release <version>",
+        Use:   "release [version]",
         Short: "Generate changelog, prepend to CHANGELOG.md, commit and tag",
-        Args:  cobra.ExactArgs(1),
+        Args:  cobra.MaximumNArgs(1),
         RunE: func(cmd *cobra.Command, args []string) error {
-            version := args[0]
+            if len(args) == 1 && bumpMode != "" {
+                return errors.New("pass either a version or --bump, not both")
+            }
+            if len(args) == 0 && bumpMode == "" {
+                return errors.New("a version argument or --bump is required")
+            }
             configuration, err := cfg.Load(releaseRepoPath)
             if err != nil {
                 return err
             }
+            t, err := resolveTarget(releaseRepoPath, configuration, releasePackage)
+            if err != nil {
+                return err
+            }
 
-            tag, _ := gitpkg.GetLatestTag(releaseRepoPath)
-            commits, err := gitpkg.GetCommitsSince(releaseRepoPath, tag)
+            tag, _ := gitpkg.GetLatestTag(releaseRepoPath, t.tagPrefix, t.tagPattern)
+            parsedCommits, skipped, err := collectCommits(releaseRepoPath, tag, "", t, configuration)
             if err != nil {
                 return err
             }
-            var parsedCommits []*parser.ParsedCommit
-            for i := range commits {
-                msg := strings.Split(commits[i].Message, "\n")[0]
-                pc, err := parser.Parse(msg)
+
+            curated := parsedCommits
+            if !noInteractive {
+                curated, skipped, err = tui.Run(parsedCommits, skipped, configuration)
                 if err != nil {
-                    continue
-                }
-                if shouldIgnoreByScope(pc.Scope, configuration) {
-                    continue
+                    return err
                 }
-                pc.Raw = &commits[i]
-                parsedCommits = append(parsedCommits, pc)
+            }
+            if err := reportSkipped(os.Stderr, skipped, releaseFailOnSkipped); err != nil {
+                return err
             }
 
-            curated := parsedCommits
-            if !noInteractive {
-                curated, err = tui.Run(parsedCommits, configuration)
+            var version string
+            if len(args) == 1 {
+                version = args[0]
+            } else {
+                next, err := nextVersion(tag, t, bumpMode, curated, configuration)
                 if err != nil {
                     return err
                 }
+                version = next.String()
             }
 
-            content, err := md.Render(versionWithV(version), curated, configuration)
+            tagName := t.tagPrefix + versionWithV(version)
+            known, err := knownContributors(releaseRepoPath, tag, t, configuration)
             if err != nil {
                 return err
             }
-            today := time.Now().Format("2006-01-02")
-            header := fmt.Sprintf("## %s - %s\n\n", versionWithV(version), today)
-            final := header + content + "\n"
-
-            if err := wf.PrependToFile(filepathJoin(releaseRepoPath, "CHANGELOG.md"), final); err != nil {
+            final, err := renderRelease(versionWithV(version), tag, tagName, time.Now(), curated, known, t, configuration)
+            if err != nil {
                 return err
             }
-            if err := wf.CommitAndTag(releaseRepoPath, versionWithV(version)); err != nil {
+
+            identity := wf.Identity{Name: configuration.Release.Identity.Name, Email: configuration.Release.Identity.Email}
+            backend, err := wf.NewBackend(configuration.Release.Backend, releaseRepoPath, identity)
+            if err != nil {
                 return err
             }
-            return nil
+            release := wf.Release{
+                RepoPath:   releaseRepoPath,
+                Changelog:  t.changelog,
+                Notes:      final,
+                Tag:        tagName,
+                Version:    versionWithV(version),
+                Force:      releaseForce,
+                Branches:   configuration.Release.Branches,
+                AllowDirty: configuration.Release.AllowDirty,
+                Links:      linkDefinitions(versionWithV(version), tag, tagName, t, configuration),
+                Push:       push || configuration.Release.Push,
+                Remote:     configuration.Release.Remote,
+                Backend:    backend,
+            }
+            if remote != "" {
+                release.Remote = remote
+            }
+            if sign || configuration.Release.Sign {
+                release.Sign = &wf.Signing{Format: configuration.Release.SigningFormat, Key: configuration.Release.SigningKey}
+                if signingKey != "" {
+                    release.Sign.Key = signingKey
+                }
+            }
+            if annotate || configuration.Release.Annotate || release.Sign != nil {
+                release.TagMessage, err = md.RenderTagMessage(configuration.Release.TagMessage, md.TagMessage{Tag: tagName, Version: versionWithV(version), Notes: final})
+                if err != nil {
+                    return fmt.Errorf("tag_message: %w", err)
+                }
+            }
+            if dryRun {
+                return release.DryRun(os.Stdout)
+            }
+            return release.Run()
         },
     }
     releaseCmd.Flags().StringVar(&releaseRepoPath, "path", ".", "Path to the git repository")
     releaseCmd.Flags().BoolVar(&noInteractive, "no-interactive", false, "Disable interactive TUI")
+    releaseCmd.Flags().StringVar(&bumpMode, "bump", "", "Compute the version instead of passing it: auto, major, minor or patch")
+    releaseCmd.Flags().StringVar(&releasePackage, "package", "", "Monorepo package from .scribe.yml to release")
+    releaseCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the release plan and changelog diff without changing anything")
+    releaseCmd.Flags().BoolVar(&annotate, "annotate", false, "Create an annotated tag carrying the release notes")
+    releaseCmd.Flags().BoolVar(&sign, "sign", false, "Sign the release commit and tag with GPG or SSH")
+    releaseCmd.Flags().BoolVar(&releaseFailOnSkipped, "fail-on-skipped", false, "Fail when commits are skipped because they are not Conventional Commits")
+    releaseCmd.Flags().BoolVar(&releaseForce, "force", false, "Replace an existing changelog section for the version")
+    releaseCmd.Flags().BoolVar(&push, "push", false, "Push the release commit and tag to the remote")
+    releaseCmd.Flags().StringVar(&remote, "remote", "", "Remote used by --push (default: the branch's upstream remote, then origin)")
+    releaseCmd.Flags().StringVar(&signingKey, "signing-key", "", "Key used with --sign: GPG key id or SSH key file")
+
+    var nextRepoPath string
+    var nextPackage string
+    var nextBump string
+
+    nextCmd := &cobra.Command{
+        Use:   "next-version",
+        Short: "Print the next version computed from the commits since the latest tag",
+        Args:  cobra.NoArgs,
+        RunE: func(cmd *cobra.Command, args []string) error {
+            configuration, err := cfg.Load(nextRepoPath)
+            if err != nil {
+                return err
+            }
+            t, err := resolveTarget(nextRepoPath, configuration, nextPackage)
+            if err != nil {
+                return err
+            }
+            tag, _ := gitpkg.GetLatestTag(nextRepoPath, t.tagPrefix, t.tagPattern)
+            parsedCommits, _, err := collectCommits(nextRepoPath, tag, "", t, configuration)
+            if err != nil {
+                return err
+            }
+            next, err := nextVersion(tag, t, nextBump, parsedCommits, configuration)
+            if err != nil {
+                return err
+            }
+            fmt.Fprintln(os.Stdout, next.String())
+            return nil
+        },
+    }
+    nextCmd.Flags().StringVar(&nextRepoPath, "path", ".", "Path to the git repository")
+    nextCmd.Flags().StringVar(&nextPackage, "package", "", "Monorepo package from .scribe.yml to compute the version for")
+    nextCmd.Flags().StringVar(&nextBump, "bump", "auto", "Increment to apply: auto, major, minor or patch")
+
+    var historyRepoPath string
+    var historyPackage string
+    var historyForce bool
+
+    historyCmd := &cobra.Command{
+        Use:   "history",
+        Short: "Regenerate the changelog for every existing release tag",
+        Args:  cobra.NoArgs,
+        RunE: func(cmd *cobra.Command, args []string) error {
+            configuration, err := cfg.Load(historyRepoPath)
+            if err != nil {
+                return err
+            }
+            t, err := resolveTarget(historyRepoPath, configuration, historyPackage)
+            if err != nil {
+                return err
+            }
+            file := filepath.Join(historyRepoPath, filepath.FromSlash(t.changelog))
+            if _, err := os.Stat(file); err == nil && !historyForce {
+                return fmt.Errorf("%s already exists; pass --force to overwrite it", t.changelog)
+            }
+
+            tags, err := gitpkg.GetVersionTags(historyRepoPath, t.tagPrefix, t.tagPattern)
+            if err != nil {
+                return err
+            }
+            if len(tags) == 0 {
+                return errors.New("no release tags found")
+            }
+
+            // Render oldest to newest, then write newest first.
+            sections := make([]string, len(tags))
+            var links []wf.LinkDefinition
+            // The authors of the releases rendered so far, to find the new
+            // contributors of each release without walking the history
+            // again.
+            known := changelog.KnownContributors{}
+            prev := ""
+            for i, tag := range tags {
+                parsedCommits, _, err := collectCommits(historyRepoPath, prev, tag.Name, t, configuration)
+                if err != nil {
+                    return err
+                }
+                version := versionWithV(strings.TrimPrefix(tag.Name, t.tagPrefix))
+                sections[len(tags)-1-i], err = renderRelease(version, prev, tag.Name, tag.Date, parsedCommits, known, t, configuration)
+                if err != nil {
+                    return err
+                }
+                if configuration.Contributors.List {
+                    raws, err := gitpkg.GetCommitsBetween(historyRepoPath, prev, tag.Name, t.paths...)
+                    if err != nil {
+                        return err
+                    }
+                    known.Add(raws)
+                }
+                for _, d := range linkDefinitions(version, prev, tag.Name, t, configuration) {
+                    if !d.ReplaceOnly {
+                        links = append([]wf.LinkDefinition{d}, links...)
+                    }
+                }
+                prev = tag.Name
+            }
+            content := md.Preamble(configuration) + strings.Join(sections, "\n")
+            if len(links) > 0 {
+                content = wf.UpdateLinkDefinitions(content, links)
+            }
+            return os.WriteFile(file, []byte(content), 0o644)
+        },
+    }
+    historyCmd.Flags().StringVar(&historyRepoPath, "path", ".", "Path to the git repository")
+    historyCmd.Flags().StringVar(&historyPackage, "package", "", "Monorepo package from .scribe.yml to regenerate the changelog for")
+    historyCmd.Flags().BoolVar(&historyForce, "force", false, "Overwrite an existing changelog")
 
-    root.AddCommand(newCmd, releaseCmd)
+    var lintRepoPath string
+    var lintFile string
+    var lintFromRef string
+    var lintToRef string
+
+    lintCmd := &cobra.Command{
+        Use:   "lint",
+        Short: "Check commit messages against Conventional Commits and the configured rules",
+        Long: "Check a commit message read from --file (\"-\" for stdin, the default) or every\n" +
+            "commit in --from-ref..--to-ref. Problems are printed to stderr and the exit\n" +
+            "status is non-zero when any message is invalid.",
+        Args: cobra.NoArgs,
+        // A failed check is not a usage error, and main prints the error.
+        SilenceUsage:  true,
+        SilenceErrors: true,
+        RunE: func(cmd *cobra.Command, args []string) error {
+            configuration, err := cfg.Load(lintRepoPath)
+            if err != nil {
+                return err
+            }
+            rules := lint.RulesFromConfig(configuration)
+
+            type message struct{ source, text string }
+            var messages []message
+            if lintFromRef != "" || lintToRef != "" {
+                if lintFile != "" {
+                    return errors.New("pass either --file or a commit range, not both")
+                }
+                commits, err := gitpkg.GetCommitsBetween(lintRepoPath, lintFromRef, lintToRef)
+                if err != nil {
+                    return err
+                }
+                for i := len(commits) - 1; i >= 0; i-- {
+                    messages = append(messages, message{source: gitpkg.ShortHash(commits[i].Hash), text: commits[i].Message})
+                }
+            } else {
+                var b []byte
+                if lintFile == "" || lintFile == "-" {
+                    b, err = io.ReadAll(cmd.InOrStdin())
+                } else {
+                    b, err = os.ReadFile(lintFile)
+                }
+                if err != nil {
+                    return err
+                }
+                source := lintFile
+                if source == "" || source == "-" {
+                    source = "stdin"
+                }
+                messages = append(messages, message{source: source, text: lint.Clean(string(b))})
+            }
+
+            invalid := 0
+            for _, m := range messages {
+                problems := lint.Message(m.text, rules)
+                if len(problems) == 0 {
+                    continue
+                }
+                invalid++
+                header, _, _ := strings.Cut(strings.TrimSpace(m.text), "\n")
+                fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", m.source, header)
+                for _, p := range problems {
+                    fmt.Fprintf(cmd.ErrOrStderr(), "  %s\n", p)
+                }
+            }
+            if invalid > 0 {
+                return fmt.Errorf("%d of %d commit message(s) failed lint", invalid, len(messages))
+            }
+            return nil
+        },
+    }
+    lintCmd.Flags().StringVar(&lintRepoPath, "path", ".", "Path to the git repository")
+    lintCmd.Flags().StringVar(&lintFile, "file", "", "File holding the commit message, or - for stdin")
+    lintCmd.Flags().StringVar(&lintFromRef, "from-ref", "", "Lint the commits after this revision")
+    lintCmd.Flags().StringVar(&lintToRef, "to-ref", "", "Lint the commits up to this revision (default: HEAD)")
+
+    var hookRepoPath string
+    var hookForce bool
+    var hookCommand string
+
+    hookCmd := &cobra.Command{
+        Use:   "hook",
+        Short: "Manage the git hooks Scribe provides",
+    }
+    hookInstallCmd := &cobra.Command{
+        Use:   "install",
+        Short: "Install a commit-msg hook that runs 'scribe lint' on every commit",
+        Args:  cobra.NoArgs,
+        RunE: func(cmd *cobra.Command, args []string) error {
+            script := fmt.Sprintf("#!/bin/sh\n# Installed by 'scribe hook install': reject commit messages that fail 'scribe lint'.\nexec %s lint --file \"$1\"\n", hookCommand)
+            file, err := wf.InstallHook(hookRepoPath, "commit-msg", script, hookForce)
+            if err != nil {
+                return err
+            }
+            fmt.Fprintf(cmd.OutOrStdout(), "Installed %s\n", file)
+            return nil
+        },
+    }
+    hookInstallCmd.Flags().StringVar(&hookRepoPath, "path", ".", "Path to the git repository")
+    hookInstallCmd.Flags().BoolVar(&hookForce, "force", false, "Replace an existing commit-msg hook")
+    hookInstallCmd.Flags().StringVar(&hookCommand, "command", "scribe", "Command the hook runs to invoke Scribe")
+    hookCmd.AddCommand(hookInstallCmd)
+
+    root.AddCommand(newCmd, releaseCmd, nextCmd, historyCmd, lintCmd, hookCmd)
 
     if err := root.Execute(); err != nil {
         fmt.Fprintln(os.Stderr, err)
@@ -143,6 +472,236 @@ func main() {
     }
 }
 
+// target is what a command releases: the whole repository, or a single
+// package of a monorepo with its own tags, files and changelog.
+type target struct {
+    tagPrefix  string
+    tagPattern string
+    paths      []string
+    changelog  string
+    forge      *forge.Forge
+}
+
+// resolveTarget returns the target for the named package, or the whole
+// repository when name is empty.
+func resolveTarget(repoPath string, configuration *cfg.Config, name string) (target, error) {
+    t := target{tagPattern: configuration.TagPattern, changelog: "CHANGELOG.md"}
+    if name != "" {
+        p, err := configuration.Package(name)
+        if err != nil {
+            return target{}, err
+        }
+        t = target{tagPrefix: p.TagPrefix, paths: []string{p.Path}, changelog: p.Changelog}
+    }
+
+    // Links are best effort: a repository without an origin remote simply
+    // renders plain hashes.
+    remote, _ := gitpkg.RemoteURL(repoPath, "origin")
+    f, err := forge.New(remote, configuration.Forge)
+    if err != nil {
+        return target{}, err
+    }
+    t.forge = f
+    return t, nil
+}
+
+// renderRelease renders one dated release of the changelog file, ending with
+// a single newline whatever the template leaves after it. The release spans the refs
+// previous..tag, which are used for the compare link; known holds the
+// contributors of the earlier releases.
+func renderRelease(version, previous, tag string, date time.Time, commits []*parser.ParsedCommit, known changelog.KnownContributors, t target, configuration *cfg.Config) (string, error) {
+    cl := changelog.Build(version, previous, date, commits, configuration)
+    cl.FindNewContributors(known)
+    cl.Link(t.forge, tag)
+    content, err := md.Render(cl, configuration)
+    if err != nil {
+        return "", err
+    }
+    return strings.TrimRight(content, "\n") + "\n", nil
+}
+
+// linkDefinitions returns the link reference definitions kept at the end of
+// the changelog when the configuration asks for them: the link of the release
+// heading for version, comparing it with previous or, for the first release,
+// pointing at its tag, and an update of the "[Unreleased]" link, if the file
+// has one, to start at tag.
+func linkDefinitions(version, previous, tag string, t target, configuration *cfg.Config) []wf.LinkDefinition {
+    if !configuration.LinkReferences() || t.forge == nil {
+        return nil
+    }
+    var defs []wf.LinkDefinition
+    url := t.forge.Tag(tag)
+    if previous != "" {
+        url = t.forge.Compare(previous, tag)
+    }
+    if url != "" {
+        defs = append(defs, wf.LinkDefinition{Label: strings.TrimPrefix(version, "v"), URL: url})
+    }
+    return append(defs, wf.LinkDefinition{Label: "Unreleased", URL: t.forge.Compare(tag, "HEAD"), ReplaceOnly: true})
+}
+
+// knownContributors returns the authors and co-authors of the history of t up
+// to ref (none when ref is empty). It walks nothing unless contributors.list
+// is set.
+func knownContributors(repoPath, ref string, t target, configuration *cfg.Config) (changelog.KnownContributors, error) {
+    known := changelog.KnownContributors{}
+    if !configuration.Contributors.List || ref == "" {
+        return known, nil
+    }
+    earlier, err := gitpkg.GetCommitsBetween(repoPath, "", ref, t.paths...)
+    if err != nil {
+        return nil, err
+    }
+    known.Add(earlier)
+    return known, nil
+}
+
+// collectCommits reads the commits in fromRef..toRef that belong to t and
+// are selected by the configured strategy. It returns those that parse as
+// Conventional Commits of a configured type and whose scope is allowed and
+// not in ignore_scopes, without the commits reverted within the range and
+// their reverts, followed by the others with the reason they were skipped.
+// An empty toRef means HEAD.
+func collectCommits(repoPath, fromRef, toRef string, t target, configuration *cfg.Config) ([]*parser.ParsedCommit, []parser.Skipped, error) {
+    var opts gitpkg.WalkOptions
+    switch configuration.Strategy {
+    case cfg.StrategyFirstParent:
+        opts.FirstParent = true
+    case cfg.StrategyMerges:
+        opts.FirstParent, opts.OnlyMerges = true, true
+    case cfg.StrategyNoMerges:
+        opts.SkipMerges = true
+    }
+    commits, err := gitpkg.GetCommitsWith(repoPath, fromRef, toRef, opts, t.paths...)
+    if err != nil {
+        return nil, nil, err
+    }
+    var parsedCommits []*parser.ParsedCommit
+    var skipped []parser.Skipped
+    for i := range commits {
+        message := commits[i].Message
+        if opts.FirstParent && commits[i].IsMerge {
+            // The merge stands for the merged branch: use the pull request
+            // title from its message.
+            if unwrapped, ok := parser.UnwrapMerge(message); ok {
+                message = unwrapped
+            }
+        }
+        pc, err := parser.ParseWith(message, configuration, configuration.IssueKeys()...)
+        if err != nil {
+            skipped = append(skipped, parser.Skipped{Raw: &commits[i], Reason: err.Error()})
+            continue
+        }
+        pc.Raw = &commits[i]
+        if shouldIgnoreByScope(pc.Scope, configuration) {
+            skipped = append(skipped, parser.Skipped{Raw: &commits[i], Reason: fmt.Sprintf("scope %q is in ignore_scopes", pc.Scope), Parsed: pc})
+            continue
+        }
+        if pc.Scope != "" && !configuration.ScopeAllowed(pc.Scope) {
+            skipped = append(skipped, parser.Skipped{Raw: &commits[i], Reason: fmt.Sprintf("scope %q is not in scopes", pc.Scope), Parsed: pc})
+            continue
+        }
+        parsedCommits = append(parsedCommits, pc)
+    }
+    parsedCommits, skipped = parser.CancelReverts(parsedCommits, skipped)
+    return parsedCommits, skipped, nil
+}
+
+// reportSkipped prints the commits left out of the changelog to w. With
+// failOnSkipped it returns an error when any of them is not a Conventional
+// Commit; commits excluded by the configuration never fail.
+func reportSkipped(w io.Writer, skipped []parser.Skipped, failOnSkipped bool) error {
+    if len(skipped) == 0 {
+        return nil
+    }
+    fmt.Fprintf(w, "Skipped %d commit(s) not in the changelog:\n", len(skipped))
+    invalid := 0
+    for _, s := range skipped {
+        fmt.Fprintf(w, "  %s %s (%s)\n", gitpkg.ShortHash(s.Raw.Hash), s.Header(), s.Reason)
+        if s.Parsed == nil {
+            invalid++
+        }
+    }
+    if failOnSkipped && invalid > 0 {
+        return fmt.Errorf("%d commit(s) are not Conventional Commits (--fail-on-skipped)", invalid)
+    }
+    return nil
+}
+
+// previousTag returns the highest release tag of t reachable from rev that does
+// not point at rev itself, or "" when rev precedes every release.
+func previousTag(repoPath, rev string, t target) (string, error) {
+    at, err := gitpkg.ResolveRevision(repoPath, rev)
+    if err != nil {
+        return "", err
+    }
+    tags, err := gitpkg.GetVersionTagsAt(repoPath, rev, t.tagPrefix, t.tagPattern)
+    if err != nil {
+        return "", err
+    }
+    for i := len(tags) - 1; i >= 0; i-- {
+        if tags[i].Commit != at {
+            return tags[i].Name, nil
+        }
+    }
+    return "", nil
+}
+
+// releaseTag returns the release tag of t pointing at the commit rev
+// resolves to, preferring a tag named rev, or nil when the commit is not a
+// release.
+func releaseTag(repoPath, rev string, t target) (*gitpkg.Tag, error) {
+    at, err := gitpkg.ResolveRevision(repoPath, rev)
+    if err != nil {
+        return nil, err
+    }
+    tags, err := gitpkg.GetVersionTagsAt(repoPath, rev, t.tagPrefix, t.tagPattern)
+    if err != nil {
+        return nil, err
+    }
+    var found *gitpkg.Tag
+    for i := range tags {
+        if tags[i].Commit == at && (found == nil || tags[i].Name == rev) {
+            found = &tags[i]
+        }
+    }
+    return found, nil
+}
+
+// nextVersion computes the version following tag. With mode "auto" the
+// increment is derived from the commits and the config's bump rules, and a
+// breaking change only bumps the minor version of a 0.x release; any other
+// mode names the increment explicitly, so "major" releases 1.0.0 from 0.x.
+// Without a previous tag, 0.0.0 is used as the starting point.
+func nextVersion(tag string, t target, mode string, commits []*parser.ParsedCommit, configuration *cfg.Config) (semver.Version, error) {
+    current := semver.Version{}
+    if tag != "" {
+        v, err := semver.Parse(strings.TrimPrefix(tag, t.tagPrefix))
+        if err != nil {
+            return semver.Version{}, fmt.Errorf("latest tag: %w", err)
+        }
+        current = v
+    }
+
+    var level bump.Level
+    var err error
+    if mode == "auto" {
+        if level, err = bump.Compute(commits, configuration.Bump); err != nil {
+            return semver.Version{}, err
+        }
+        level = bump.Demote(current, level)
+    } else if level, err = bump.ParseLevel(mode); err != nil {
+        return semver.Version{}, err
+    }
+    if level == bump.None {
+        if tag == "" {
+            return semver.Version{}, errors.New("no commits warrant a release")
+        }
+        return semver.Version{}, fmt.Errorf("no commits since %s warrant a release", tag)
+    }
+    return bump.Next(current, level), nil
+}
+
 func versionWithV(v string) string {
     if strings.HasPrefix(v, "v") {
         return v
@@ -150,11 +709,6 @@ func versionWithV(v string) string {
     return "v" + v
 }
 
-func filepathJoin(elem ...string) string {
-    // Avoid importing path/filepath to keep main minimal and OS-agnostic
-    return strings.Join(elem, string(os.PathSeparator))
-}
-
 func shouldIgnoreByScope(scope string, c *cfg.Config) bool {
     if scope == "" {
         return false
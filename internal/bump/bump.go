@@ -0,0 +1,90 @@
+package bump
+
+import (
+	"fmt"
+
+	"github.com/felipevolpatto/scribe/internal/parser"
+	"github.com/felipevolpatto/scribe/internal/semver"
+)
+
+// Level is the size of a version increment.
+type Level int
+
+const (
+    None Level = iota
+    Patch
+    Minor
+    Major
+)
+
+// String returns the lower-case name used in .scribe.yml and on the command line.
+func (l Level) String() string {
+    switch l {
+    case Patch:
+        return "patch"
+    case Minor:
+        return "minor"
+    case Major:
+        return "major"
+    }
+    return "none"
+}
+
+// ParseLevel converts "major", "minor", "patch" or "none" into a Level.
+func ParseLevel(s string) (Level, error) {
+    switch s {
+    case "major":
+        return Major, nil
+    case "minor":
+        return Minor, nil
+    case "patch":
+        return Patch, nil
+    case "none", "":
+        return None, nil
+    }
+    return None, fmt.Errorf("unknown bump level %q (expected major, minor, patch or none)", s)
+}
+
+// Compute returns the largest increment required by the given commits.
+// Breaking changes always require a major bump; every other commit is looked
+// up by type in rules, a map of commit type to level name.
+func Compute(commits []*parser.ParsedCommit, rules map[string]string) (Level, error) {
+    level := None
+    for _, pc := range commits {
+        if pc.IsBreaking {
+            return Major, nil
+        }
+        l, err := ParseLevel(rules[pc.Type])
+        if err != nil {
+            return None, fmt.Errorf("bump rule for type %q: %w", pc.Type, err)
+        }
+        if l > level {
+            level = l
+        }
+    }
+    return level, nil
+}
+
+// Demote limits a level computed from the commits while the major version
+// is 0: the public API is not considered stable yet, so breaking changes bump
+// the minor version instead of releasing 1.0.0. An explicitly requested level
+// is applied as is.
+func Demote(current semver.Version, level Level) Level {
+    if level == Major && current.Major == 0 {
+        return Minor
+    }
+    return level
+}
+
+// Next applies level to current.
+func Next(current semver.Version, level Level) semver.Version {
+    switch level {
+    case Major:
+        return current.IncMajor()
+    case Minor:
+        return current.IncMinor()
+    case Patch:
+        return current.IncPatch()
+    }
+    return current
+}
@@ -0,0 +1,78 @@
+package bump
+
+import (
+	"testing"
+
+	"github.com/felipevolpatto/scribe/internal/parser"
+	"github.com/felipevolpatto/scribe/internal/semver"
+)
+
+var rules = map[string]string{"feat": "minor", "fix": "patch", "perf": "patch"}
+
+func TestCompute(t *testing.T) {
+    tests := []struct {
+        name    string
+        commits []*parser.ParsedCommit
+        want    Level
+    }{
+        {"empty", nil, None},
+        {"chore only", []*parser.ParsedCommit{{Type: "chore"}}, None},
+        {"fix", []*parser.ParsedCommit{{Type: "chore"}, {Type: "fix"}}, Patch},
+        {"perf", []*parser.ParsedCommit{{Type: "perf"}}, Patch},
+        {"feat wins over fix", []*parser.ParsedCommit{{Type: "fix"}, {Type: "feat"}}, Minor},
+        {"breaking", []*parser.ParsedCommit{{Type: "feat"}, {Type: "chore", IsBreaking: true}}, Major},
+    }
+    for _, tt := range tests {
+        got, err := Compute(tt.commits, rules)
+        if err != nil {
+            t.Fatalf("%s: unexpected error: %v", tt.name, err)
+        }
+        if got != tt.want {
+            t.Fatalf("%s: got %s, want %s", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestCompute_InvalidRule(t *testing.T) {
+    if _, err := Compute([]*parser.ParsedCommit{{Type: "feat"}}, map[string]string{"feat": "huge"}); err == nil {
+        t.Fatal("expected error for unknown level")
+    }
+}
+
+func TestNext(t *testing.T) {
+    tests := []struct {
+        current string
+        level   Level
+        want    string
+    }{
+        {"1.2.3", Major, "2.0.0"},
+        {"1.2.3", Minor, "1.3.0"},
+        {"1.2.3", Patch, "1.2.4"},
+        {"1.2.3", None, "1.2.3"},
+        {"0.4.1", Major, "1.0.0"},
+        {"0.4.1", Minor, "0.5.0"},
+        {"0.4.1", Patch, "0.4.2"},
+    }
+    for _, tt := range tests {
+        cur, err := semver.Parse(tt.current)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got := Next(cur, tt.level).String(); got != tt.want {
+            t.Fatalf("Next(%s, %s): got %s, want %s", tt.current, tt.level, got, tt.want)
+        }
+    }
+}
+
+func TestDemote(t *testing.T) {
+    zero, one := semver.Version{Minor: 4, Patch: 1}, semver.Version{Major: 1}
+    if got := Demote(zero, Major); got != Minor {
+        t.Fatalf("Demote(0.4.1, major): got %s, want minor", got)
+    }
+    if got := Demote(zero, Patch); got != Patch {
+        t.Fatalf("Demote(0.4.1, patch): got %s, want patch", got)
+    }
+    if got := Demote(one, Major); got != Major {
+        t.Fatalf("Demote(1.0.0, major): got %s, want major", got)
+    }
+}
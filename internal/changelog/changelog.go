@@ -1,16 +1,280 @@
 package changelog
 
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	cfg "github.com/felipevolpatto/scribe/internal/config"
+	"github.com/felipevolpatto/scribe/internal/forge"
+	gitpkg "github.com/felipevolpatto/scribe/internal/git"
+	"github.com/felipevolpatto/scribe/internal/parser"
+)
+
 // Changelog represents the final, curated data before Markdown generation.
+// It is also the data passed to user-defined templates, so exported field
+// names are part of Scribe's configuration surface.
 type Changelog struct {
-    Version  string
-    Date     string
-    Sections []ChangelogSection
+    Version         string
+    PreviousVersion string
+    Date            time.Time
+    Sections        []ChangelogSection
+    // Forge and CompareURL are set by Link when the hosting service is known.
+    Forge      *forge.Forge
+    CompareURL string
+    // Contributors lists everyone credited in the release when
+    // contributors.list is set; NewContributors, filled by
+    // FindNewContributors, those whose first commit is in it.
+    Contributors    []Contributor
+    NewContributors []Contributor
 }
 
 // ChangelogSection contains a list of items for a specific category.
+// Breaking is set for the section that collects breaking changes.
 type ChangelogSection struct {
+    Title    string
+    Breaking bool
+    // Items are sorted by the order configured for the section.
+    Items []Item
+    // ScopeStyle is how the scope of the items is shown: "none", "heading"
+    // or "prefix".
+    ScopeStyle string
+}
+
+// ScopeGroup is the items of a section sharing a scope.
+type ScopeGroup struct {
+    Scope string
+    // Title is the display name of Scope, empty for unscoped items.
     Title string
-    Items []string
+    Items []Item
+}
+
+// Item is a single commit listed in a section.
+type Item struct {
+    Type         string
+    Scope        string
+    // ScopeTitle is the display name of Scope from scope_names.
+    ScopeTitle   string
+    Description  string
+    Hash         string
+    Author       string
+    AuthorEmail  string
+    CoAuthors    []gitpkg.Person
+    // By credits the author and co-authors, e.g. "@jane and @joe", when
+    // contributors.by_line is set.
+    By           string
+    Date         time.Time
+    Body         string
+    Footers      []parser.Footer
+    IsBreaking   bool
+    BreakingNote string
+    // References lists the issues referenced by the commit; Build fills in
+    // the URLs from the issue trackers and Link those from the forge.
+    References []Reference
+    // PullRequest is the pull request the commit landed with, e.g. "#123".
+    PullRequest string
+    CommitURL   string
+    // Reverts is the hash of the commit a revert undoes; RevertsURL is set
+    // by Link when the forge is known.
+    Reverts    string
+    RevertsURL string
+}
+
+// Reference is an issue referenced by a commit, with its web URL when known.
+type Reference struct {
+    parser.IssueRef
+    URL string
+}
+
+// Issues returns the "#123" references of the commit's own repository, in
+// the order of References.
+func (it Item) Issues() []string {
+    var out []string
+    for _, r := range it.References {
+        if r.Repo == "" && !r.IsKey() {
+            out = append(out, r.ID)
+        }
+    }
+    return out
 }
 
+// TrailingReferences returns the references that the description does not
+// mention, which the default templates append to the item.
+func (it Item) TrailingReferences() []Reference {
+    var out []Reference
+    for _, r := range it.References {
+        if !r.InSubject {
+            out = append(out, r)
+        }
+    }
+    return out
+}
+
+// Build groups the curated commits into the sections configured in
+// .scribe.yml. A commit is listed in every section whose types include its
+// type, compared by canonical type name so that sections may use aliases;
+// breaking commits are additionally listed in any section with no types.
+// Sections without items are omitted.
+func Build(version, previous string, date time.Time, commits []*parser.ParsedCommit, config *cfg.Config) *Changelog {
+    cl := &Changelog{Version: version, PreviousVersion: previous, Date: date}
+    for _, section := range config.Sections {
+        s := ChangelogSection{Title: section.Title, Breaking: len(section.Types) == 0}
+        for _, pc := range commits {
+            // Route breaking changes to any section with empty types
+            if pc.IsBreaking && len(section.Types) == 0 {
+                s.Items = append(s.Items, NewItem(pc))
+                continue
+            }
+            for _, t := range section.Types {
+                if canonical, ok := config.CanonicalType(t); ok {
+                    t = canonical
+                }
+                if pc.Type == t {
+                    s.Items = append(s.Items, NewItem(pc))
+                    break
+                }
+            }
+        }
+        if len(s.Items) > 0 {
+            for i := range s.Items {
+                s.Items[i].ScopeTitle = config.ScopeTitle(s.Items[i].Scope)
+                for j := range s.Items[i].References {
+                    r := &s.Items[i].References[j]
+                    r.URL = config.IssueURL(r.Repo, r.ID)
+                }
+                if config.Contributors.ByLine {
+                    it := s.Items[i]
+                    s.Items[i].By = byLine(commitContributors(&gitpkg.RawCommit{AuthorName: it.Author, AuthorEmail: it.AuthorEmail, CoAuthors: it.CoAuthors}, config))
+                }
+            }
+            sortItems(s.Items, config.SectionOrder(section))
+            s.ScopeStyle = config.SectionScopeStyle(section)
+            cl.Sections = append(cl.Sections, s)
+        }
+    }
+    if config.Contributors.List {
+        cl.Contributors = contributors(commits, config)
+    }
+    return cl
+}
+
+// sortItems puts items, which are in curated order, in the given order.
+func sortItems(items []Item, order string) {
+    switch order {
+    case cfg.OrderChronological:
+        // Commits come newest first; reversing keeps commits with the same
+        // date in the order they were made.
+        slices.Reverse(items)
+        sort.SliceStable(items, func(i, j int) bool { return items[i].Date.Before(items[j].Date) })
+    case cfg.OrderAlphabetical:
+        sort.SliceStable(items, func(i, j int) bool {
+            return strings.ToLower(items[i].Description) < strings.ToLower(items[j].Description)
+        })
+    case cfg.OrderScope:
+        sort.SliceStable(items, func(i, j int) bool {
+            a, b := items[i], items[j]
+            if (a.Scope == "") != (b.Scope == "") {
+                return a.Scope == ""
+            }
+            return strings.ToLower(a.ScopeTitle) < strings.ToLower(b.ScopeTitle)
+        })
+    }
+}
 
+// Groups returns Items split by scope when ScopeStyle is "heading", the
+// unscoped items first and the scopes in the order of their first item;
+// otherwise it returns a single untitled group with every item. The groups
+// are derived from Items on every call, so they reflect later changes such
+// as the URLs set by Link.
+func (s ChangelogSection) Groups() []ScopeGroup {
+    if s.ScopeStyle != cfg.ScopeStyleHeading {
+        return []ScopeGroup{{Items: s.Items}}
+    }
+    var groups []ScopeGroup
+    index := map[string]int{}
+    if slices.ContainsFunc(s.Items, func(it Item) bool { return it.Scope == "" }) {
+        groups, index[""] = append(groups, ScopeGroup{}), 0
+    }
+    for _, it := range s.Items {
+        i, ok := index[it.Scope]
+        if !ok {
+            i = len(groups)
+            index[it.Scope] = i
+            groups = append(groups, ScopeGroup{Scope: it.Scope, Title: it.ScopeTitle})
+        }
+        groups[i].Items = append(groups[i].Items, it)
+    }
+    return groups
+}
+
+// Link records the hosting service of the repository and fills in the web
+// URLs of every commit and of the comparison between PreviousVersion and to,
+// the ref the release ends at (usually its tag). A nil forge leaves the
+// changelog without links.
+func (cl *Changelog) Link(f *forge.Forge, to string) {
+    if f == nil {
+        return
+    }
+    cl.Forge = f
+    if cl.PreviousVersion != "" && to != "" {
+        cl.CompareURL = f.Compare(cl.PreviousVersion, to)
+    }
+    link := func(it *Item) {
+        if it.Hash != "" {
+            it.CommitURL = f.Commit(it.Hash)
+        }
+        if it.Reverts != "" {
+            it.RevertsURL = f.Commit(it.Reverts)
+        }
+        for i := range it.References {
+            r := &it.References[i]
+            switch {
+            case r.URL != "" || r.IsKey():
+            case r.Repo != "":
+                r.URL = f.RepoIssue(r.Repo, r.ID)
+            default:
+                r.URL = f.Issue(r.ID)
+            }
+        }
+    }
+    for i := range cl.Sections {
+        s := &cl.Sections[i]
+        for j := range s.Items {
+            link(&s.Items[j])
+        }
+    }
+    for _, people := range [][]Contributor{cl.Contributors, cl.NewContributors} {
+        for i := range people {
+            if people[i].FirstCommit != "" {
+                people[i].FirstCommitURL = f.Commit(people[i].FirstCommit)
+            }
+        }
+    }
+}
+
+// NewItem copies the fields of a parsed commit into a changelog item.
+func NewItem(pc *parser.ParsedCommit) Item {
+    it := Item{
+        Type:         pc.Type,
+        Scope:        pc.Scope,
+        Description:  pc.Description,
+        Body:         pc.Body,
+        Footers:      pc.Footers,
+        IsBreaking:   pc.IsBreaking,
+        BreakingNote: pc.BreakingNote,
+        PullRequest:  pc.PullRequest,
+        Reverts:      pc.Reverts,
+    }
+    for _, r := range pc.References {
+        it.References = append(it.References, Reference{IssueRef: r})
+    }
+    if pc.Raw != nil {
+        it.Hash = pc.Raw.Hash
+        it.Author = pc.Raw.AuthorName
+        it.AuthorEmail = pc.Raw.AuthorEmail
+        it.CoAuthors = pc.Raw.CoAuthors
+        it.Date = pc.Raw.AuthorDate
+    }
+    return it
+}
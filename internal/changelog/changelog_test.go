@@ -0,0 +1,159 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cfg "github.com/felipevolpatto/scribe/internal/config"
+	"github.com/felipevolpatto/scribe/internal/forge"
+	gitpkg "github.com/felipevolpatto/scribe/internal/git"
+	"github.com/felipevolpatto/scribe/internal/parser"
+)
+
+func TestBuild_RoutesCommitsToSections(t *testing.T) {
+    config := cfg.Default()
+    date := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+    commits := []*parser.ParsedCommit{
+        {Type: "feat", Scope: "api", Description: "add login", Raw: &gitpkg.RawCommit{Hash: "abcdef1", AuthorName: "Jane"}},
+        {Type: "fix", Description: "correct bug"},
+        {Type: "chore", Description: "bump deps"},
+        {Type: "feat", Description: "drop v1", IsBreaking: true, BreakingNote: "v1 is gone"},
+    }
+    cl := Build("v2.0.0", "v1.0.0", date, commits, config)
+
+    if cl.Version != "v2.0.0" || cl.PreviousVersion != "v1.0.0" || !cl.Date.Equal(date) {
+        t.Fatalf("unexpected release metadata: %+v", cl)
+    }
+    if len(cl.Sections) != 3 {
+        t.Fatalf("expected 3 non-empty sections, got %+v", cl.Sections)
+    }
+    breaking := cl.Sections[0]
+    if breaking.Title != "Breaking Changes" || !breaking.Breaking || len(breaking.Items) != 1 || breaking.Items[0].BreakingNote != "v1 is gone" {
+        t.Fatalf("unexpected breaking section: %+v", breaking)
+    }
+    features := cl.Sections[1]
+    if features.Title != "New Features" || features.Breaking || len(features.Items) != 2 {
+        t.Fatalf("unexpected features section: %+v", features)
+    }
+    first := features.Items[0]
+    if first.Scope != "api" || first.Hash != "abcdef1" || first.Author != "Jane" {
+        t.Fatalf("commit metadata not copied into item: %+v", first)
+    }
+    if cl.Sections[2].Title != "Bug Fixes" || len(cl.Sections[2].Items) != 1 {
+        t.Fatalf("unexpected fixes section: %+v", cl.Sections[2])
+    }
+}
+
+func TestBuild_OmitsEmptySections(t *testing.T) {
+    cl := Build("v1.0.0", "", time.Time{}, []*parser.ParsedCommit{{Type: "docs", Description: "readme"}}, cfg.Default())
+    if len(cl.Sections) != 0 {
+        t.Fatalf("expected no sections for unmapped types, got %+v", cl.Sections)
+    }
+}
+
+func TestBuild_SectionOrder(t *testing.T) {
+    day := func(d int) *gitpkg.RawCommit {
+        return &gitpkg.RawCommit{AuthorDate: time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC)}
+    }
+    // Newest first, as git log lists them.
+    commits := []*parser.ParsedCommit{
+        {Type: "feat", Scope: "web", Description: "Cache pages", Raw: day(3)},
+        {Type: "feat", Description: "add login", Raw: day(2)},
+        {Type: "feat", Scope: "api", Description: "batch requests", Raw: day(1)},
+    }
+    tests := []struct {
+        order string
+        want  []string
+    }{
+        {cfg.OrderCurated, []string{"Cache pages", "add login", "batch requests"}},
+        {cfg.OrderChronological, []string{"batch requests", "add login", "Cache pages"}},
+        {cfg.OrderAlphabetical, []string{"add login", "batch requests", "Cache pages"}},
+        {cfg.OrderScope, []string{"add login", "batch requests", "Cache pages"}},
+    }
+    for _, tt := range tests {
+        config := cfg.Default()
+        config.Sections = []cfg.Section{{Title: "Features", Types: []string{"feat"}, Order: tt.order}}
+        cl := Build("v1.0.0", "", time.Time{}, commits, config)
+        var got []string
+        for _, it := range cl.Sections[0].Items {
+            got = append(got, it.Description)
+        }
+        if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+            t.Errorf("order %s: got %v, want %v", tt.order, got, tt.want)
+        }
+    }
+}
+
+func TestBuild_ScopeGroups(t *testing.T) {
+    config := cfg.Default()
+    config.ScopeStyle = cfg.ScopeStyleHeading
+    config.ScopeNames = map[string]string{"api": "Public API"}
+    commits := []*parser.ParsedCommit{
+        {Type: "feat", Scope: "api", Description: "a"},
+        {Type: "feat", Scope: "cli", Description: "b"},
+        {Type: "feat", Description: "c"},
+        {Type: "feat", Scope: "api", Description: "d"},
+    }
+    groups := Build("v1.0.0", "", time.Time{}, commits, config).Sections[0].Groups()
+    if len(groups) != 3 || groups[0].Title != "" || groups[1].Title != "Public API" || len(groups[1].Items) != 2 || groups[2].Scope != "cli" {
+        t.Fatalf("unexpected scope groups: %+v", groups)
+    }
+}
+
+func TestLink_ScopeGroups(t *testing.T) {
+    config := cfg.Default()
+    config.ScopeStyle = cfg.ScopeStyleHeading
+    f, err := forge.New("git@github.com:owner/repo.git", cfg.Forge{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    commits := []*parser.ParsedCommit{
+        {Type: "feat", Scope: "api", Description: "a", Raw: &gitpkg.RawCommit{Hash: "1111111aaa"}},
+        {Type: "feat", Description: "b", Raw: &gitpkg.RawCommit{Hash: "2222222bbb"}},
+    }
+    cl := Build("v1.0.0", "", time.Time{}, commits, config)
+    cl.Link(f, "v1.0.0")
+    for _, g := range cl.Sections[0].Groups() {
+        for _, it := range g.Items {
+            if it.CommitURL != "https://github.com/owner/repo/commit/"+it.Hash {
+                t.Fatalf("group %q item %q not linked: %+v", g.Scope, it.Description, it)
+            }
+        }
+    }
+}
+
+func TestBuild_Contributors(t *testing.T) {
+    config := cfg.Default()
+    config.Contributors = cfg.Contributors{
+        Handles: map[string]string{"jane@example.com": "janedoe"},
+        ByLine:  true,
+        List:    true,
+    }
+    // Newest first, as git log lists them.
+    commits := []*parser.ParsedCommit{
+        {Type: "fix", Description: "b", Raw: &gitpkg.RawCommit{Hash: "2222222", AuthorName: "Octo Cat", AuthorEmail: "1+octocat@users.noreply.github.com"}},
+        {Type: "feat", Description: "a", Raw: &gitpkg.RawCommit{
+            Hash: "1111111", AuthorName: "Jane", AuthorEmail: "jane@example.com",
+            CoAuthors: []gitpkg.Person{{Name: "Joe Bloggs", Email: "joe@example.com"}, {Name: "Octo Cat", Email: "1+octocat@users.noreply.github.com"}},
+        }},
+    }
+    cl := Build("v1.1.0", "v1.0.0", time.Time{}, commits, config)
+    if by := cl.Sections[0].Items[0].By; by != "@janedoe, Joe Bloggs and @octocat" {
+        t.Fatalf("unexpected by-line %q", by)
+    }
+    var mentions []string
+    for _, c := range cl.Contributors {
+        mentions = append(mentions, c.Mention()+"="+c.FirstCommit)
+    }
+    if strings.Join(mentions, ",") != "@janedoe=1111111,@octocat=1111111,Joe Bloggs=1111111" {
+        t.Fatalf("unexpected contributors %v", mentions)
+    }
+
+    known := KnownContributors{}
+    known.Add([]gitpkg.RawCommit{{AuthorName: "Jane", AuthorEmail: "JANE@example.com"}})
+    cl.FindNewContributors(known)
+    if len(cl.NewContributors) != 2 || cl.NewContributors[0].Handle != "octocat" || cl.NewContributors[1].Name != "Joe Bloggs" {
+        t.Fatalf("unexpected new contributors %+v", cl.NewContributors)
+    }
+}
@@ -0,0 +1,138 @@
+package changelog
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	cfg "github.com/felipevolpatto/scribe/internal/config"
+	gitpkg "github.com/felipevolpatto/scribe/internal/git"
+	"github.com/felipevolpatto/scribe/internal/parser"
+)
+
+// Contributor is a person credited in a release: the author or a co-author
+// of one of its commits.
+type Contributor struct {
+    Name  string
+    Email string
+    // Handle is the person's handle on the forge, without the "@"; empty
+    // when unknown.
+    Handle string
+    // FirstCommit is the hash of the person's oldest commit in the release;
+    // FirstCommitURL is set by Link when the forge is known.
+    FirstCommit    string
+    FirstCommitURL string
+}
+
+// Mention returns "@handle", or the name when the handle is unknown.
+func (c Contributor) Mention() string {
+    if c.Handle != "" {
+        return "@" + c.Handle
+    }
+    return c.Name
+}
+
+// key identifies a person across commits: the email, or the name when the
+// commit has no email.
+func (c Contributor) key() string {
+    if c.Email != "" {
+        return strings.ToLower(c.Email)
+    }
+    return strings.ToLower(c.Name)
+}
+
+// Matches a GitHub noreply address such as
+// "12345+octocat@users.noreply.github.com".
+// Groups: 1=handle
+var noreplyRe = regexp.MustCompile(`(?i)^(?:\d+\+)?([^@]+)@users\.noreply\.github\.com$`)
+
+// newContributor returns the contributor for a name and email, with the
+// handle from contributors.handles or a GitHub noreply address.
+func newContributor(name, email string, config *cfg.Config) Contributor {
+    c := Contributor{Name: name, Email: email}
+    if h := config.Contributors.Handles[strings.ToLower(email)]; h != "" {
+        c.Handle = strings.TrimPrefix(h, "@")
+    } else if m := noreplyRe.FindStringSubmatch(email); m != nil {
+        c.Handle = m[1]
+    }
+    return c
+}
+
+// commitContributors returns the author and co-authors of a commit, without
+// duplicates.
+func commitContributors(raw *gitpkg.RawCommit, config *cfg.Config) []Contributor {
+    if raw == nil {
+        return nil
+    }
+    var out []Contributor
+    seen := map[string]bool{}
+    add := func(name, email string) {
+        c := newContributor(name, email, config)
+        if c.key() != "" && !seen[c.key()] {
+            seen[c.key()] = true
+            out = append(out, c)
+        }
+    }
+    add(raw.AuthorName, raw.AuthorEmail)
+    for _, p := range raw.CoAuthors {
+        add(p.Name, p.Email)
+    }
+    return out
+}
+
+// contributors returns everyone credited in commits, which are newest
+// first, sorted by mention.
+func contributors(commits []*parser.ParsedCommit, config *cfg.Config) []Contributor {
+    var out []Contributor
+    index := map[string]int{}
+    for i := len(commits) - 1; i >= 0; i-- {
+        for _, c := range commitContributors(commits[i].Raw, config) {
+            if _, ok := index[c.key()]; ok {
+                continue
+            }
+            c.FirstCommit = commits[i].Raw.Hash
+            index[c.key()] = len(out)
+            out = append(out, c)
+        }
+    }
+    sort.SliceStable(out, func(i, j int) bool {
+        return strings.ToLower(out[i].Mention()) < strings.ToLower(out[j].Mention())
+    })
+    return out
+}
+
+// byLine joins the mentions of people as in "@a, @b and @c".
+func byLine(people []Contributor) string {
+    mentions := make([]string, len(people))
+    for i, p := range people {
+        mentions[i] = p.Mention()
+    }
+    if len(mentions) < 2 {
+        return strings.Join(mentions, "")
+    }
+    return strings.Join(mentions[:len(mentions)-1], ", ") + " and " + mentions[len(mentions)-1]
+}
+
+// KnownContributors is the set of people who authored or co-authored the
+// commits made before a release.
+type KnownContributors map[string]bool
+
+// Add records the authors and co-authors of commits.
+func (k KnownContributors) Add(commits []gitpkg.RawCommit) {
+    for i := range commits {
+        for _, c := range commitContributors(&commits[i], &cfg.Config{}) {
+            k[c.key()] = true
+        }
+    }
+}
+
+// FindNewContributors sets NewContributors to the Contributors who are not
+// known, i.e. whose first commit is in this release.
+func (cl *Changelog) FindNewContributors(known KnownContributors) {
+    cl.NewContributors = nil
+    for _, c := range cl.Contributors {
+        if !known[c.key()] {
+            cl.NewContributors = append(cl.NewContributors, c)
+        }
+    }
+}
@@ -2,36 +2,479 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/spf13/viper"
 )
 
+// PresetKeepAChangelog formats the changelog as described on
+// https://keepachangelog.com.
+const PresetKeepAChangelog = "keepachangelog"
+
 // Config represents the loaded .scribe.yml file.
 type Config struct {
+    // Preset selects a built-in changelog style that provides default
+    // sections and a default template. The only preset is "keepachangelog".
+    Preset string `yaml:"preset" mapstructure:"preset"`
+
     Sections     []Section `yaml:"sections" mapstructure:"sections"`
     IgnoreScopes []string  `yaml:"ignore_scopes" mapstructure:"ignore_scopes"`
+    // Bump maps a commit type to the version increment it requires
+    // ("major", "minor", "patch" or "none"). Breaking changes always bump major.
+    Bump map[string]string `yaml:"bump" mapstructure:"bump"`
+    // TagPattern is a glob restricting which tags are considered releases,
+    // e.g. "v*". Empty means every tag that parses as a semantic version.
+    TagPattern string `yaml:"tag_pattern" mapstructure:"tag_pattern"`
+    // Strategy selects the commits of a release: all (the default),
+    // first-parent, merges or no-merges.
+    Strategy string `yaml:"strategy" mapstructure:"strategy"`
+    // Order is the order of the items inside every section: curated (the
+    // default), chronological, alphabetical or scope.
+    Order string `yaml:"order" mapstructure:"order"`
+    // ScopeStyle shows the scope of the items: none (the default), heading
+    // or prefix.
+    ScopeStyle string `yaml:"scope_style" mapstructure:"scope_style"`
+    // ScopeNames maps scopes to the names shown in the changelog, e.g.
+    // api to "Public API".
+    ScopeNames map[string]string `yaml:"scope_names" mapstructure:"scope_names"`
+    // Packages declares independently released parts of a monorepo.
+    Packages []Package `yaml:"packages" mapstructure:"packages"`
+    // Template is a Go text/template rendering one release. When
+    // TemplateFile is set instead, Load reads the file into Template.
+    Template     string `yaml:"template" mapstructure:"template"`
+    TemplateFile string `yaml:"template_file" mapstructure:"template_file"`
+    // Forge configures links to the repository's hosting service.
+    Forge Forge `yaml:"forge" mapstructure:"forge"`
+    // Issues lists the issue trackers commit messages refer to besides the
+    // forge's own issues, which "#123" references link to.
+    Issues []IssueTracker `yaml:"issues" mapstructure:"issues"`
+    // Release configures the checks made before 'scribe release' changes anything.
+    Release Release `yaml:"release" mapstructure:"release"`
+    // Types declares the allowed commit types with their descriptions and
+    // aliases. Empty allows the Conventional Commits types plus the types
+    // used by sections and bump. Parsing, linting, the TUI and the
+    // changelog all go through this registry.
+    Types Types `yaml:"types" mapstructure:"types"`
+    // Scopes lists the allowed scopes; empty allows any scope.
+    Scopes Scopes `yaml:"scopes" mapstructure:"scopes"`
+    // Lint configures the rules 'scribe lint' checks commit messages against.
+    Lint Lint `yaml:"lint" mapstructure:"lint"`
+    // Contributors configures how commit authors are credited.
+    Contributors Contributors `yaml:"contributors" mapstructure:"contributors"`
+}
+
+// Contributors configures the crediting of commit authors and co-authors
+// (from Co-authored-by trailers) in the release notes.
+type Contributors struct {
+    // Handles maps emails to handles on the forge, e.g. jane@example.com to
+    // janedoe, which is shown as "@janedoe". GitHub noreply addresses need no
+    // entry.
+    Handles map[string]string `yaml:"handles" mapstructure:"handles"`
+    // ByLine appends "by @handle" (or the name when there is no handle) to
+    // every item.
+    ByLine bool `yaml:"by_line" mapstructure:"by_line"`
+    // List adds a "Contributors" block listing everyone credited in the
+    // release and a "New contributors" block with those whose first commit
+    // is in it.
+    List bool `yaml:"list" mapstructure:"list"`
+}
+
+// Type is a commit type of the registry. Aliases, like the name, are matched
+// case-insensitively, so "Feature: x" can be normalized to "feat".
+type Type struct {
+    Name        string   `yaml:"name" mapstructure:"name"`
+    Description string   `yaml:"description" mapstructure:"description"`
+    Aliases     []string `yaml:"aliases" mapstructure:"aliases"`
+}
+
+// Types is the registry of allowed commit types.
+type Types []Type
+
+// Lint holds the commit message rules besides the Conventional Commits
+// grammar.
+type Lint struct {
+    // RequireScope rejects commits without a scope.
+    RequireScope bool `yaml:"require_scope" mapstructure:"require_scope"`
+    // MaxHeaderLength limits the length of the first line; 0 disables it.
+    MaxHeaderLength int `yaml:"max_header_length" mapstructure:"max_header_length"`
+}
+
+// Release holds the preflight rules of the release workflow.
+type Release struct {
+    // Branches lists globs of branches releases may be made from; empty
+    // allows any branch.
+    Branches []string `yaml:"branches" mapstructure:"branches"`
+    // AllowDirty lists files that may have uncommitted changes besides the
+    // changelog.
+    AllowDirty []string `yaml:"allow_dirty" mapstructure:"allow_dirty"`
+    // Backend selects how the release commit and tag are created: "native"
+    // (go-git, the default) or "exec" (the git binary, which runs hooks).
+    Backend string `yaml:"backend" mapstructure:"backend"`
+    // Identity overrides the author and committer of the release commit;
+    // by default user.name and user.email come from the git configuration.
+    Identity Identity `yaml:"identity" mapstructure:"identity"`
+    // Annotate creates an annotated tag whose message is the release notes,
+    // or TagMessage when set.
+    Annotate bool `yaml:"annotate" mapstructure:"annotate"`
+    // TagMessage is a Go template for the annotated tag message with the
+    // fields .Tag, .Version and .Notes.
+    TagMessage string `yaml:"tag_message" mapstructure:"tag_message"`
+    // Sign signs the release commit and tag (which is then annotated).
+    Sign bool `yaml:"sign" mapstructure:"sign"`
+    // SigningFormat is gpg or ssh; empty uses gpg.format from git config.
+    SigningFormat string `yaml:"signing_format" mapstructure:"signing_format"`
+    // SigningKey is the GPG key id or SSH key file; empty uses
+    // user.signingkey from git config.
+    SigningKey string `yaml:"signing_key" mapstructure:"signing_key"`
+    // Push pushes the release commit and tag after creating them.
+    Push bool `yaml:"push" mapstructure:"push"`
+    // Remote is the remote to push to; empty uses the branch's upstream
+    // remote, then origin.
+    Remote string `yaml:"remote" mapstructure:"remote"`
+}
+
+// Identity is a git author identity.
+type Identity struct {
+    Name  string `yaml:"name" mapstructure:"name"`
+    Email string `yaml:"email" mapstructure:"email"`
+}
+
+// Forge overrides how links to commits, comparisons and issues are built.
+// Everything is optional: by default the repository URL is derived from the
+// origin remote and the type from its host name.
+type Forge struct {
+    // Type is one of github, gitlab, bitbucket or gitea.
+    Type string `yaml:"type" mapstructure:"type"`
+    // URL is the repository web URL, e.g. https://git.example.com/org/repo.
+    URL string `yaml:"url" mapstructure:"url"`
+    // CommitURL, CompareURL, TagURL and IssueURL are patterns using the
+    // placeholders {url}, {hash}, {from}, {to}, {tag} and {id}.
+    CommitURL  string `yaml:"commit_url" mapstructure:"commit_url"`
+    CompareURL string `yaml:"compare_url" mapstructure:"compare_url"`
+    TagURL     string `yaml:"tag_url" mapstructure:"tag_url"`
+    IssueURL   string `yaml:"issue_url" mapstructure:"issue_url"`
+    // Disable turns links off entirely.
+    Disable bool `yaml:"disable" mapstructure:"disable"`
+}
+
+// IssueTracker links the issue references of another tracker: "org/repo#123"
+// references to issues of other repositories on GitHub or GitLab, or
+// "PROJ-123" keys to Jira issues.
+type IssueTracker struct {
+    // Type is github, gitlab or jira.
+    Type string `yaml:"type" mapstructure:"type"`
+    // Keys lists the Jira project keys, e.g. PROJ; required for jira.
+    Keys []string `yaml:"keys" mapstructure:"keys"`
+    // URL is the pattern of an issue's web URL, using the placeholders {id}
+    // (the number, or the key such as PROJ-123) and {repo} ("org/repo").
+    // Required for jira; github and gitlab default to github.com and
+    // gitlab.com.
+    URL string `yaml:"url" mapstructure:"url"`
+}
+
+// The default URL patterns of the github and gitlab issue trackers.
+var issueURLs = map[string]string{
+    "github": "https://github.com/{repo}/issues/{id}",
+    "gitlab": "https://gitlab.com/{repo}/-/issues/{id}",
+}
+
+// Matches a Jira project key.
+var issueKeyRe = regexp.MustCompile(`^[A-Z][A-Z0-9_]+$`)
+
+// Package is an independently versioned part of a monorepo, selected with
+// --package on the command line.
+type Package struct {
+    Name string `yaml:"name" mapstructure:"name"`
+    // Path is a glob, relative to the repository root, selecting the files
+    // that belong to the package, e.g. "services/api".
+    Path string `yaml:"path" mapstructure:"path"`
+    // TagPrefix precedes the version in the package's tags, e.g. "api/" for
+    // "api/v1.4.0". Defaults to the package name followed by a slash.
+    TagPrefix string `yaml:"tag_prefix" mapstructure:"tag_prefix"`
+    // Changelog is the file the package's releases are written to, relative
+    // to the repository root. Defaults to CHANGELOG.md inside Path.
+    Changelog string `yaml:"changelog" mapstructure:"changelog"`
 }
 
 // Section defines a single category in the final changelog.
 type Section struct {
     Title string   `yaml:"title" mapstructure:"title"`
     Types []string `yaml:"types" mapstructure:"types"`
+    // Order and ScopeStyle override the top-level settings of the same name
+    // for this section.
+    Order      string `yaml:"order" mapstructure:"order"`
+    ScopeStyle string `yaml:"scope_style" mapstructure:"scope_style"`
+}
+
+// The strategies selecting the commits of a release.
+const (
+    // StrategyAll takes every commit of the range, like 'git log'.
+    StrategyAll = "all"
+    // StrategyFirstParent takes the commits made on the branch and the
+    // merges into it, whose pull request title stands for the merged
+    // branch, like 'git log --first-parent'.
+    StrategyFirstParent = "first-parent"
+    // StrategyMerges takes only the merges into the branch.
+    StrategyMerges = "merges"
+    // StrategyNoMerges takes every commit except merge commits.
+    StrategyNoMerges = "no-merges"
+)
+
+// The orders of the items inside a section.
+const (
+    // OrderCurated keeps the order of the commits as curated, which is git
+    // log order (newest first) unless changed in the TUI.
+    OrderCurated = "curated"
+    // OrderChronological lists the oldest commit first.
+    OrderChronological = "chronological"
+    // OrderAlphabetical sorts by description.
+    OrderAlphabetical = "alphabetical"
+    // OrderScope sorts by scope display name, unscoped items first, keeping
+    // the curated order within a scope.
+    OrderScope = "scope"
+)
+
+// The ways the scope of an item is shown.
+const (
+    // ScopeStyleNone leaves the scope out.
+    ScopeStyleNone = "none"
+    // ScopeStyleHeading groups the items under a bold heading per scope.
+    ScopeStyleHeading = "heading"
+    // ScopeStylePrefix prefixes each item with "**scope:**".
+    ScopeStylePrefix = "prefix"
+)
+
+// SectionOrder returns the item order of s, falling back to the top-level
+// order and then OrderCurated.
+func (c *Config) SectionOrder(s Section) string {
+    for _, o := range []string{s.Order, c.Order} {
+        if o != "" {
+            return o
+        }
+    }
+    return OrderCurated
+}
+
+// SectionScopeStyle returns the scope style of s, falling back to the
+// top-level style and then ScopeStyleNone.
+func (c *Config) SectionScopeStyle(s Section) string {
+    for _, st := range []string{s.ScopeStyle, c.ScopeStyle} {
+        if st != "" {
+            return st
+        }
+    }
+    return ScopeStyleNone
+}
+
+// ScopeTitle returns the display name of scope from ScopeNames, or the scope
+// itself when it has none. Scopes are matched ignoring case because the
+// configuration loader lower-cases map keys.
+func (c *Config) ScopeTitle(scope string) string {
+    if title := c.ScopeNames[scope]; title != "" {
+        return title
+    }
+    if title := c.ScopeNames[strings.ToLower(scope)]; title != "" {
+        return title
+    }
+    return scope
 }
 
 // Default returns the default configuration when no .scribe.yml is present.
 func Default() *Config {
-    return &Config{
+    c := &Config{
         Sections: []Section{
             {Title: "Breaking Changes", Types: []string{}},
             {Title: "New Features", Types: []string{"feat"}},
             {Title: "Bug Fixes", Types: []string{"fix"}},
+            {Title: "Reverts", Types: []string{"revert"}},
         },
         IgnoreScopes: []string{},
+        Bump: map[string]string{
+            "feat": "minor",
+            "fix":  "patch",
+            "perf": "patch",
+        },
+    }
+    c.Types = defaultTypes(c)
+    return c
+}
+
+// DefaultTypes returns the types of the Conventional Commits convention.
+func DefaultTypes() Types {
+    return Types{
+        {Name: "feat", Description: "A new feature"},
+        {Name: "fix", Description: "A bug fix"},
+        {Name: "docs", Description: "Documentation only changes"},
+        {Name: "style", Description: "Changes that do not affect the meaning of the code"},
+        {Name: "refactor", Description: "A code change that neither fixes a bug nor adds a feature"},
+        {Name: "perf", Description: "A code change that improves performance"},
+        {Name: "test", Description: "Adding missing tests or correcting existing tests"},
+        {Name: "build", Description: "Changes that affect the build system or external dependencies"},
+        {Name: "ci", Description: "Changes to the CI configuration"},
+        {Name: "chore", Description: "Other changes that don't modify source or test files"},
+        {Name: "revert", Description: "Reverts a previous commit"},
     }
 }
 
+// defaultTypes returns DefaultTypes followed by the other types used by the
+// sections and bump rules of c.
+func defaultTypes(c *Config) Types {
+    types := DefaultTypes()
+    seen := map[string]bool{}
+    for _, t := range types {
+        seen[t.Name] = true
+    }
+    var extra []string
+    for _, s := range c.Sections {
+        for _, t := range s.Types {
+            if !seen[t] {
+                seen[t] = true
+                extra = append(extra, t)
+            }
+        }
+    }
+    var bumped []string
+    for t := range c.Bump {
+        if !seen[t] {
+            seen[t] = true
+            bumped = append(bumped, t)
+        }
+    }
+    sort.Strings(bumped)
+    for _, t := range append(extra, bumped...) {
+        types = append(types, Type{Name: t})
+    }
+    return types
+}
+
+// Canonical returns the name of the type that typ names, directly or through
+// an alias, ignoring case.
+func (ts Types) Canonical(typ string) (string, bool) {
+    for _, t := range ts {
+        if strings.EqualFold(t.Name, typ) {
+            return t.Name, true
+        }
+        for _, a := range t.Aliases {
+            if strings.EqualFold(a, typ) {
+                return t.Name, true
+            }
+        }
+    }
+    return "", false
+}
+
+// Names returns the type names in declaration order.
+func (ts Types) Names() []string {
+    names := make([]string, len(ts))
+    for i, t := range ts {
+        names[i] = t.Name
+    }
+    return names
+}
+
+// Description returns the description of the type called name.
+func (ts Types) Description(name string) string {
+    for _, t := range ts {
+        if t.Name == name {
+            return t.Description
+        }
+    }
+    return ""
+}
+
+// CanonicalType resolves typ against the configured types; see
+// Types.Canonical.
+func (c *Config) CanonicalType(typ string) (string, bool) {
+    return c.Types.Canonical(typ)
+}
+
+// Scopes is the list of allowed commit scopes.
+type Scopes []string
+
+// Allowed reports whether scope may be used: any scope is allowed when the
+// list is empty.
+func (s Scopes) Allowed(scope string) bool {
+    if len(s) == 0 {
+        return true
+    }
+    for _, v := range s {
+        if v == scope {
+            return true
+        }
+    }
+    return false
+}
+
+// ScopeAllowed reports whether scope may be used: any scope is allowed when
+// Scopes is empty.
+func (c *Config) ScopeAllowed(scope string) bool {
+    return c.Scopes.Allowed(scope)
+}
+
+// IssueKeys returns the project keys of the jira issue trackers.
+func (c *Config) IssueKeys() []string {
+    var keys []string
+    for _, t := range c.Issues {
+        if t.Type == "jira" {
+            keys = append(keys, t.Keys...)
+        }
+    }
+    return keys
+}
+
+// IssueURL returns the web URL of an issue of another tracker: the issue id
+// ("#123") of repo ("org/repo") on the first github or gitlab tracker, or
+// the issue key id ("PROJ-123") on the jira tracker of its project. It
+// returns "" for issues of the repository itself, which the forge links, and
+// for issues no tracker covers.
+func (c *Config) IssueURL(repo, id string) string {
+    for _, t := range c.Issues {
+        pattern := t.URL
+        switch {
+        case t.Type == "jira" && repo == "" && !strings.HasPrefix(id, "#"):
+            project, _, _ := strings.Cut(id, "-")
+            if !slices.Contains(t.Keys, project) {
+                continue
+            }
+        case t.Type != "jira" && repo != "":
+            if pattern == "" {
+                pattern = issueURLs[t.Type]
+            }
+        default:
+            continue
+        }
+        return strings.NewReplacer("{repo}", repo, "{id}", strings.TrimPrefix(id, "#")).Replace(pattern)
+    }
+    return ""
+}
+
+// KeepAChangelogSections maps commit types to the sections of Keep a
+// Changelog. Breaking changes stay in the section of their type, and reverts
+// of earlier releases are listed as removed.
+func KeepAChangelogSections() []Section {
+    return []Section{
+        {Title: "Added", Types: []string{"feat"}},
+        {Title: "Changed", Types: []string{"perf", "refactor"}},
+        {Title: "Deprecated", Types: []string{"deprecate"}},
+        {Title: "Removed", Types: []string{"remove", "revert"}},
+        {Title: "Fixed", Types: []string{"fix"}},
+        {Title: "Security", Types: []string{"security"}},
+    }
+}
+
+// LinkReferences reports whether the changelog keeps reference-style
+// compare links ("[1.2.0]: https://...") at the end of the file.
+func (c *Config) LinkReferences() bool {
+    return c.Preset == PresetKeepAChangelog
+}
+
 // Load reads the configuration from <repoPath>/.scribe.yml. If the file does not
 // exist, it returns the default configuration.
 func Load(repoPath string) (*Config, error) {
@@ -59,7 +502,9 @@ func Load(repoPath string) (*Config, error) {
         return def, nil
     }
 
-    v := viper.New()
+    // Map keys such as the emails of contributors.handles contain dots,
+    // which viper would otherwise split into nested keys.
+    v := viper.NewWithOptions(viper.KeyDelimiter("::"))
     v.SetConfigFile(configFile)
 
     if err := v.ReadInConfig(); err != nil {
@@ -69,14 +514,151 @@ func Load(repoPath string) (*Config, error) {
     if err := v.Unmarshal(cfg); err != nil {
         return nil, err
     }
+    // The sections and bump rules of the file must use declared types; the
+    // defaults merged below may mention types the file left out.
+    var declared []string
+    for _, s := range cfg.Sections {
+        declared = append(declared, s.Types...)
+    }
+    for t := range cfg.Bump {
+        declared = append(declared, t)
+    }
+    sort.Strings(declared)
     // Merge defaults for any missing fields
+    switch cfg.Preset {
+    case "":
+    case PresetKeepAChangelog:
+        def.Sections = KeepAChangelogSections()
+    default:
+        return nil, fmt.Errorf("%s: unknown preset %q (expected %s)", configFile, cfg.Preset, PresetKeepAChangelog)
+    }
     if len(cfg.Sections) == 0 {
         cfg.Sections = def.Sections
     }
     if cfg.IgnoreScopes == nil {
         cfg.IgnoreScopes = def.IgnoreScopes
     }
+    if len(cfg.Bump) == 0 {
+        cfg.Bump = def.Bump
+    }
+    if len(cfg.Types) == 0 {
+        cfg.Types = defaultTypes(cfg)
+    }
+    names := map[string]bool{}
+    for i, t := range cfg.Types {
+        if t.Name == "" {
+            return nil, fmt.Errorf("%s: types[%d] requires a name", configFile, i)
+        }
+        for _, n := range append([]string{t.Name}, t.Aliases...) {
+            if names[strings.ToLower(n)] {
+                return nil, fmt.Errorf("%s: type or alias %q is declared twice", configFile, n)
+            }
+            names[strings.ToLower(n)] = true
+        }
+    }
+    for _, t := range declared {
+        if _, ok := cfg.Types.Canonical(t); !ok {
+            return nil, fmt.Errorf("%s: type %q is used by sections or bump but not declared in types", configFile, t)
+        }
+    }
+    switch cfg.Strategy {
+    case "", StrategyAll, StrategyFirstParent, StrategyMerges, StrategyNoMerges:
+    default:
+        return nil, fmt.Errorf("%s: strategy must be all, first-parent, merges or no-merges, got %q", configFile, cfg.Strategy)
+    }
+    for i, t := range cfg.Issues {
+        switch t.Type {
+        case "github", "gitlab":
+        case "jira":
+            if len(t.Keys) == 0 || t.URL == "" {
+                return nil, fmt.Errorf("%s: issues[%d] of type jira requires keys and a url", configFile, i)
+            }
+            for _, k := range t.Keys {
+                if !issueKeyRe.MatchString(k) {
+                    return nil, fmt.Errorf("%s: issues[%d]: Jira keys are upper-case letters and digits, got %q", configFile, i, k)
+                }
+            }
+        default:
+            return nil, fmt.Errorf("%s: issues[%d].type must be github, gitlab or jira, got %q", configFile, i, t.Type)
+        }
+    }
+    for _, s := range append([]Section{{Order: cfg.Order, ScopeStyle: cfg.ScopeStyle}}, cfg.Sections...) {
+        switch s.Order {
+        case "", OrderCurated, OrderChronological, OrderAlphabetical, OrderScope:
+        default:
+            return nil, fmt.Errorf("%s: order must be curated, chronological, alphabetical or scope, got %q", configFile, s.Order)
+        }
+        switch s.ScopeStyle {
+        case "", ScopeStyleNone, ScopeStyleHeading, ScopeStylePrefix:
+        default:
+            return nil, fmt.Errorf("%s: scope_style must be none, heading or prefix, got %q", configFile, s.ScopeStyle)
+        }
+    }
+    if cfg.TemplateFile != "" {
+        if cfg.Template != "" {
+            return nil, fmt.Errorf("%s: set either template or template_file, not both", configFile)
+        }
+        file := cfg.TemplateFile
+        if !filepath.IsAbs(file) {
+            file = filepath.Join(repoPath, file)
+        }
+        b, err := os.ReadFile(file)
+        if err != nil {
+            return nil, fmt.Errorf("%s: template_file: %w", configFile, err)
+        }
+        cfg.Template = string(b)
+    }
+    switch cfg.Release.Backend {
+    case "", "native", "exec":
+    default:
+        return nil, fmt.Errorf("%s: release.backend must be native or exec, got %q", configFile, cfg.Release.Backend)
+    }
+    switch cfg.Release.SigningFormat {
+    case "", "gpg", "ssh":
+    default:
+        return nil, fmt.Errorf("%s: release.signing_format must be gpg or ssh, got %q", configFile, cfg.Release.SigningFormat)
+    }
+    seen := map[string]bool{}
+    for i := range cfg.Packages {
+        p := &cfg.Packages[i]
+        if p.Name == "" || p.Path == "" {
+            return nil, fmt.Errorf("%s: packages[%d] requires a name and a path", configFile, i)
+        }
+        if seen[p.Name] {
+            return nil, fmt.Errorf("%s: duplicate package %q", configFile, p.Name)
+        }
+        seen[p.Name] = true
+        if p.TagPrefix == "" {
+            p.TagPrefix = p.Name + "/"
+        }
+        if p.Changelog == "" {
+            p.Changelog = path.Join(globBase(p.Path), "CHANGELOG.md")
+        }
+    }
     return cfg, nil
 }
 
+// Package returns the package with the given name.
+func (c *Config) Package(name string) (*Package, error) {
+    for i := range c.Packages {
+        if c.Packages[i].Name == name {
+            return &c.Packages[i], nil
+        }
+    }
+    return nil, fmt.Errorf("unknown package %q", name)
+}
+
+// globBase returns the leading directories of a glob that contain no pattern
+// characters, e.g. "services/api" for "services/api/**".
+func globBase(pattern string) string {
+    var base []string
+    for _, part := range strings.Split(pattern, "/") {
+        if strings.ContainsAny(part, "*?[\\") {
+            break
+        }
+        base = append(base, part)
+    }
+    return path.Join(base...)
+}
+
 
@@ -36,3 +36,253 @@ func TestLoad_FromFile(t *testing.T) {
 }
 
 
+
+func TestLoad_BumpRules(t *testing.T) {
+    dir := t.TempDir()
+    content := []byte("bump:\n  feat: minor\n  refactor: patch\n")
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), content, 0o644); err != nil {
+        t.Fatalf("write config: %v", err)
+    }
+    c, err := Load(dir)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if c.Bump["refactor"] != "patch" || c.Bump["feat"] != "minor" {
+        t.Fatalf("expected custom bump rules, got %+v", c.Bump)
+    }
+    if _, ok := c.Bump["fix"]; ok {
+        t.Fatalf("custom bump rules should replace the defaults, got %+v", c.Bump)
+    }
+}
+
+func TestLoad_Packages(t *testing.T) {
+    dir := t.TempDir()
+    content := []byte("packages:\n  - { name: api, path: services/api/** }\n  - { name: worker, path: worker, tag_prefix: 'wrk-', changelog: docs/WORKER.md }\n")
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), content, 0o644); err != nil {
+        t.Fatalf("write config: %v", err)
+    }
+    c, err := Load(dir)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    api, err := c.Package("api")
+    if err != nil {
+        t.Fatalf("Package(api): %v", err)
+    }
+    if api.TagPrefix != "api/" || api.Changelog != "services/api/CHANGELOG.md" {
+        t.Fatalf("expected package defaults, got %+v", api)
+    }
+    worker, _ := c.Package("worker")
+    if worker.TagPrefix != "wrk-" || worker.Changelog != "docs/WORKER.md" {
+        t.Fatalf("expected explicit package settings, got %+v", worker)
+    }
+    if _, err := c.Package("web"); err == nil {
+        t.Fatal("expected error for unknown package")
+    }
+}
+
+func TestLoad_PackageRequiresPath(t *testing.T) {
+    dir := t.TempDir()
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte("packages:\n  - { name: api }\n"), 0o644); err != nil {
+        t.Fatalf("write config: %v", err)
+    }
+    if _, err := Load(dir); err == nil {
+        t.Fatal("expected error for package without path")
+    }
+}
+
+func TestLoad_TemplateFile(t *testing.T) {
+    dir := t.TempDir()
+    if err := os.WriteFile(filepath.Join(dir, "notes.tmpl"), []byte("{{ .Version }}"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte("template_file: notes.tmpl\n"), 0o644); err != nil {
+        t.Fatalf("write config: %v", err)
+    }
+    c, err := Load(dir)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if c.Template != "{{ .Version }}" {
+        t.Fatalf("expected template read from file, got %q", c.Template)
+    }
+}
+
+func TestLoad_Preset(t *testing.T) {
+    dir := t.TempDir()
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte("preset: keepachangelog\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    c, err := Load(dir)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if len(c.Sections) != 6 || c.Sections[0].Title != "Added" || c.Sections[4].Title != "Fixed" {
+        t.Fatalf("expected Keep a Changelog sections, got %+v", c.Sections)
+    }
+    if !c.LinkReferences() {
+        t.Fatal("expected link references for the keepachangelog preset")
+    }
+
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte("preset: nope\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := Load(dir); err == nil {
+        t.Fatal("expected an error for an unknown preset")
+    }
+}
+
+func TestLoad_DefaultTypes(t *testing.T) {
+    dir := t.TempDir()
+    content := []byte("sections:\n  - { title: Security, types: [security] }\n")
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), content, 0o644); err != nil {
+        t.Fatal(err)
+    }
+    c, err := Load(dir)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    for _, want := range []string{"feat", "chore", "security"} {
+        if typ, ok := c.CanonicalType(want); !ok || typ != want {
+            t.Fatalf("expected %q in the default types %v", want, c.Types.Names())
+        }
+    }
+    if c.Types.Description("feat") == "" {
+        t.Fatal("expected a description for feat")
+    }
+}
+
+func TestLoad_TypesAndAliases(t *testing.T) {
+    dir := t.TempDir()
+    content := []byte(`types:
+  - { name: feat, description: A new feature, aliases: [feature] }
+  - { name: fix, aliases: [bugfix] }
+scopes: [api]
+`)
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), content, 0o644); err != nil {
+        t.Fatal(err)
+    }
+    c, err := Load(dir)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    for in, want := range map[string]string{"Feature": "feat", "BUGFIX": "fix", "fix": "fix"} {
+        if typ, ok := c.CanonicalType(in); !ok || typ != want {
+            t.Fatalf("CanonicalType(%q) = %q, %v; want %q", in, typ, ok, want)
+        }
+    }
+    if _, ok := c.CanonicalType("chore"); ok {
+        t.Fatal("expected chore to be rejected when types are declared")
+    }
+    if !c.ScopeAllowed("api") || c.ScopeAllowed("web") {
+        t.Fatalf("unexpected scope check for scopes %v", c.Scopes)
+    }
+}
+
+func TestLoad_TypesValidation(t *testing.T) {
+    for _, content := range []string{
+        "types:\n  - { name: feat, aliases: [feature] }\n  - { name: feature }\n",
+        "types:\n  - { description: no name }\n",
+        "types:\n  - { name: fix }\nbump: { feat: minor }\n",
+        "types:\n  - { name: fix }\nsections:\n  - { title: Features, types: [feat] }\n",
+    } {
+        dir := t.TempDir()
+        if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte(content), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        if _, err := Load(dir); err == nil {
+            t.Fatalf("expected an error for %q", content)
+        }
+    }
+}
+
+func TestLoad_SectionOrder(t *testing.T) {
+    dir := t.TempDir()
+    content := []byte(`order: chronological
+scope_style: prefix
+scope_names: { api: Public API }
+sections:
+  - { title: Features, types: [feat], order: scope, scope_style: heading }
+  - { title: Fixes, types: [fix] }
+`)
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), content, 0o644); err != nil {
+        t.Fatal(err)
+    }
+    c, err := Load(dir)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if c.SectionOrder(c.Sections[0]) != OrderScope || c.SectionScopeStyle(c.Sections[0]) != ScopeStyleHeading {
+        t.Fatalf("section settings not used: %+v", c.Sections[0])
+    }
+    if c.SectionOrder(c.Sections[1]) != OrderChronological || c.SectionScopeStyle(c.Sections[1]) != ScopeStylePrefix {
+        t.Fatalf("top-level settings not used: %+v", c.Sections[1])
+    }
+    if c.ScopeTitle("api") != "Public API" || c.ScopeTitle("cli") != "cli" {
+        t.Fatalf("unexpected scope titles for %v", c.ScopeNames)
+    }
+
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte("sections:\n  - { title: Features, types: [feat], order: random }\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := Load(dir); err == nil {
+        t.Fatal("expected an error for an unknown order")
+    }
+}
+
+func TestLoad_ContributorHandles(t *testing.T) {
+    dir := t.TempDir()
+    content := []byte("contributors:\n  by_line: true\n  handles:\n    Jane@Example.com: janedoe\n")
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), content, 0o644); err != nil {
+        t.Fatal(err)
+    }
+    c, err := Load(dir)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if !c.Contributors.ByLine || c.Contributors.Handles["jane@example.com"] != "janedoe" {
+        t.Fatalf("unexpected contributors config: %+v", c.Contributors)
+    }
+}
+
+func TestLoad_IssueTrackers(t *testing.T) {
+    dir := t.TempDir()
+    content := []byte(`issues:
+  - { type: jira, keys: [PROJ, OPS], url: "https://example.atlassian.net/browse/{id}" }
+  - { type: github }
+`)
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), content, 0o644); err != nil {
+        t.Fatal(err)
+    }
+    c, err := Load(dir)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if keys := c.IssueKeys(); len(keys) != 2 || keys[0] != "PROJ" {
+        t.Fatalf("unexpected issue keys: %v", keys)
+    }
+    tests := []struct{ repo, id, want string }{
+        {"", "PROJ-123", "https://example.atlassian.net/browse/PROJ-123"},
+        {"", "ABC-1", ""},
+        {"org/repo", "#9", "https://github.com/org/repo/issues/9"},
+        {"", "#9", ""},
+    }
+    for _, tt := range tests {
+        if got := c.IssueURL(tt.repo, tt.id); got != tt.want {
+            t.Errorf("IssueURL(%q, %q) = %q, want %q", tt.repo, tt.id, got, tt.want)
+        }
+    }
+
+    for _, content := range []string{
+        "issues:\n  - { type: jira, url: \"https://example.atlassian.net/browse/{id}\" }\n",
+        "issues:\n  - { type: jira, keys: [proj], url: \"https://example.atlassian.net/browse/{id}\" }\n",
+        "issues:\n  - { type: redmine }\n",
+    } {
+        if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte(content), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        if _, err := Load(dir); err == nil {
+            t.Fatalf("expected an error for %q", content)
+        }
+    }
+}
@@ -0,0 +1,158 @@
+package export
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/felipevolpatto/scribe/internal/changelog"
+	gitpkg "github.com/felipevolpatto/scribe/internal/git"
+)
+
+// SchemaVersion is the version of the Release document layout. It is bumped
+// whenever a field is removed or changes meaning; new optional fields do not
+// change it. See docs/release-schema.json.
+const SchemaVersion = 1
+
+// Release is the machine-readable form of a changelog release.
+type Release struct {
+    SchemaVersion   int       `json:"schema_version" yaml:"schema_version"`
+    Version         string    `json:"version" yaml:"version"`
+    PreviousVersion string    `json:"previous_version,omitempty" yaml:"previous_version,omitempty"`
+    Date            string    `json:"date,omitempty" yaml:"date,omitempty"`
+    CompareURL      string    `json:"compare_url,omitempty" yaml:"compare_url,omitempty"`
+    Sections        []Section `json:"sections" yaml:"sections"`
+    // Contributors and NewContributors are filled when contributors.list
+    // is set in .scribe.yml.
+    Contributors    []Contributor `json:"contributors,omitempty" yaml:"contributors,omitempty"`
+    NewContributors []Contributor `json:"new_contributors,omitempty" yaml:"new_contributors,omitempty"`
+}
+
+// Section is a titled group of items.
+type Section struct {
+    Title    string `json:"title" yaml:"title"`
+    Breaking bool   `json:"breaking" yaml:"breaking"`
+    Items    []Item `json:"items" yaml:"items"`
+}
+
+// Item is a single commit of a release.
+type Item struct {
+    Type         string      `json:"type" yaml:"type"`
+    Scope        string      `json:"scope,omitempty" yaml:"scope,omitempty"`
+    ScopeTitle   string      `json:"scope_title,omitempty" yaml:"scope_title,omitempty"`
+    Description  string      `json:"description" yaml:"description"`
+    Hash         string      `json:"hash,omitempty" yaml:"hash,omitempty"`
+    ShortHash    string      `json:"short_hash,omitempty" yaml:"short_hash,omitempty"`
+    CommitURL    string      `json:"commit_url,omitempty" yaml:"commit_url,omitempty"`
+    Author       *Person     `json:"author,omitempty" yaml:"author,omitempty"`
+    CoAuthors    []Person    `json:"co_authors,omitempty" yaml:"co_authors,omitempty"`
+    Date         string      `json:"date,omitempty" yaml:"date,omitempty"`
+    Breaking     bool        `json:"breaking" yaml:"breaking"`
+    BreakingNote string      `json:"breaking_note,omitempty" yaml:"breaking_note,omitempty"`
+    Issues       []string    `json:"issues,omitempty" yaml:"issues,omitempty"`
+    References   []Reference `json:"references,omitempty" yaml:"references,omitempty"`
+    PullRequest  string      `json:"pull_request,omitempty" yaml:"pull_request,omitempty"`
+    Reverts      string      `json:"reverts,omitempty" yaml:"reverts,omitempty"`
+}
+
+// Reference is an issue referenced by a commit.
+type Reference struct {
+    Action string `json:"action,omitempty" yaml:"action,omitempty"`
+    Repo   string `json:"repo,omitempty" yaml:"repo,omitempty"`
+    ID     string `json:"id" yaml:"id"`
+    URL    string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// Person identifies a commit author.
+type Person struct {
+    Name  string `json:"name" yaml:"name"`
+    Email string `json:"email,omitempty" yaml:"email,omitempty"`
+}
+
+// Contributor is a person credited in a release.
+type Contributor struct {
+    Name        string `json:"name" yaml:"name"`
+    Email       string `json:"email,omitempty" yaml:"email,omitempty"`
+    Handle      string `json:"handle,omitempty" yaml:"handle,omitempty"`
+    FirstCommit string `json:"first_commit,omitempty" yaml:"first_commit,omitempty"`
+}
+
+// FromChangelog converts a built changelog into its exported form. Release
+// dates use YYYY-MM-DD and commit dates RFC 3339; zero dates are omitted.
+func FromChangelog(cl *changelog.Changelog) Release {
+    r := Release{
+        SchemaVersion:   SchemaVersion,
+        Version:         cl.Version,
+        PreviousVersion: cl.PreviousVersion,
+        CompareURL:      cl.CompareURL,
+        Sections:        []Section{},
+    }
+    if !cl.Date.IsZero() {
+        r.Date = cl.Date.Format("2006-01-02")
+    }
+    for _, s := range cl.Sections {
+        section := Section{Title: s.Title, Breaking: s.Breaking, Items: []Item{}}
+        for _, it := range s.Items {
+            item := Item{
+                Type:         it.Type,
+                Scope:        it.Scope,
+                ScopeTitle:   scopeTitle(it),
+                Description:  it.Description,
+                Hash:         it.Hash,
+                ShortHash:    gitpkg.ShortHash(it.Hash),
+                CommitURL:    it.CommitURL,
+                Breaking:     it.IsBreaking,
+                BreakingNote: it.BreakingNote,
+                Issues:       it.Issues(),
+                PullRequest:  it.PullRequest,
+                Reverts:      it.Reverts,
+            }
+            if it.Author != "" || it.AuthorEmail != "" {
+                item.Author = &Person{Name: it.Author, Email: it.AuthorEmail}
+            }
+            for _, ref := range it.References {
+                item.References = append(item.References, Reference{Action: ref.Action, Repo: ref.Repo, ID: ref.ID, URL: ref.URL})
+            }
+            for _, p := range it.CoAuthors {
+                item.CoAuthors = append(item.CoAuthors, Person{Name: p.Name, Email: p.Email})
+            }
+            if !it.Date.IsZero() {
+                item.Date = it.Date.Format(time.RFC3339)
+            }
+            section.Items = append(section.Items, item)
+        }
+        r.Sections = append(r.Sections, section)
+    }
+    r.Contributors = contributors(cl.Contributors)
+    r.NewContributors = contributors(cl.NewContributors)
+    return r
+}
+
+func contributors(people []changelog.Contributor) []Contributor {
+    var out []Contributor
+    for _, p := range people {
+        out = append(out, Contributor{Name: p.Name, Email: p.Email, Handle: p.Handle, FirstCommit: p.FirstCommit})
+    }
+    return out
+}
+
+// scopeTitle returns the display name of the item's scope when it differs
+// from the scope itself.
+func scopeTitle(it changelog.Item) string {
+    if it.ScopeTitle == it.Scope {
+        return ""
+    }
+    return it.ScopeTitle
+}
+
+// JSON renders the changelog as an indented JSON document.
+func JSON(cl *changelog.Changelog) ([]byte, error) {
+    return json.MarshalIndent(FromChangelog(cl), "", "  ")
+}
+
+// YAML renders the changelog as a YAML document.
+func YAML(cl *changelog.Changelog) ([]byte, error) {
+    return yaml.Marshal(FromChangelog(cl))
+}
+
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felipevolpatto/scribe/internal/changelog"
+	cfg "github.com/felipevolpatto/scribe/internal/config"
+	gitpkg "github.com/felipevolpatto/scribe/internal/git"
+	"github.com/felipevolpatto/scribe/internal/parser"
+)
+
+func buildChangelog() *changelog.Changelog {
+    commits := []*parser.ParsedCommit{
+        {
+            Type:        "feat",
+            Scope:       "api",
+            Description: "add login (#12)",
+            Footers:     []parser.Footer{{Token: "Closes", Value: "#40"}},
+            References:  []parser.IssueRef{{ID: "#12", InSubject: true}, {Action: "closes", ID: "#40"}},
+            Raw: &gitpkg.RawCommit{
+                Hash:        "abcdef1234567890",
+                AuthorName:  "Jane Doe",
+                AuthorEmail: "jane@example.com",
+                AuthorDate:  time.Date(2024, 4, 30, 12, 0, 0, 0, time.UTC),
+            },
+        },
+        {Type: "fix", Description: "drop v1", IsBreaking: true, BreakingNote: "v1 removed"},
+    }
+    return changelog.Build("v2.0.0", "v1.0.0", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), commits, cfg.Default())
+}
+
+func TestJSON(t *testing.T) {
+    b, err := JSON(buildChangelog())
+    if err != nil {
+        t.Fatalf("JSON: %v", err)
+    }
+    var r Release
+    if err := json.Unmarshal(b, &r); err != nil {
+        t.Fatalf("output is not valid JSON: %v", err)
+    }
+    if r.SchemaVersion != SchemaVersion || r.Version != "v2.0.0" || r.PreviousVersion != "v1.0.0" || r.Date != "2024-05-01" {
+        t.Fatalf("unexpected release metadata: %+v", r)
+    }
+    if len(r.Sections) != 3 || !r.Sections[0].Breaking {
+        t.Fatalf("unexpected sections: %+v", r.Sections)
+    }
+    feat := r.Sections[1].Items[0]
+    if feat.Hash != "abcdef1234567890" || feat.ShortHash != "abcdef1" || feat.Scope != "api" {
+        t.Fatalf("unexpected item: %+v", feat)
+    }
+    if feat.Author == nil || feat.Author.Email != "jane@example.com" || feat.Date != "2024-04-30T12:00:00Z" {
+        t.Fatalf("unexpected author or date: %+v", feat)
+    }
+    if strings.Join(feat.Issues, ",") != "#12,#40" {
+        t.Fatalf("unexpected issues: %v", feat.Issues)
+    }
+    if len(feat.References) != 2 || feat.References[1] != (Reference{Action: "closes", ID: "#40"}) {
+        t.Fatalf("unexpected references: %+v", feat.References)
+    }
+    breaking := r.Sections[0].Items[0]
+    if !breaking.Breaking || breaking.BreakingNote != "v1 removed" || breaking.Author != nil {
+        t.Fatalf("unexpected breaking item: %+v", breaking)
+    }
+}
+
+func TestYAML(t *testing.T) {
+    b, err := YAML(buildChangelog())
+    if err != nil {
+        t.Fatalf("YAML: %v", err)
+    }
+    out := string(b)
+    for _, want := range []string{"schema_version: 1", "version: v2.0.0", "short_hash: abcdef1", "breaking_note: v1 removed"} {
+        if !strings.Contains(out, want) {
+            t.Fatalf("expected %q in YAML output:\n%s", want, out)
+        }
+    }
+}
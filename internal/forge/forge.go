@@ -0,0 +1,215 @@
+package forge
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	cfg "github.com/felipevolpatto/scribe/internal/config"
+)
+
+// Forge builds web links into a hosted repository. URL patterns may use the
+// placeholders {url} (repository web URL), {hash}, {from}, {to}, {tag} and
+// {id}.
+type Forge struct {
+    URL        string
+    CommitURL  string
+    CompareURL string
+    TagURL     string
+    IssueURL   string
+}
+
+// Patterns of the supported hosting services, keyed by type.
+var presets = map[string]Forge{
+    "github": {
+        CommitURL:  "{url}/commit/{hash}",
+        CompareURL: "{url}/compare/{from}...{to}",
+        TagURL:     "{url}/releases/tag/{tag}",
+        IssueURL:   "{url}/issues/{id}",
+    },
+    "gitlab": {
+        CommitURL:  "{url}/-/commit/{hash}",
+        CompareURL: "{url}/-/compare/{from}...{to}",
+        TagURL:     "{url}/-/tags/{tag}",
+        IssueURL:   "{url}/-/issues/{id}",
+    },
+    "bitbucket": {
+        CommitURL:  "{url}/commits/{hash}",
+        CompareURL: "{url}/branches/compare/{to}%0D{from}",
+        TagURL:     "{url}/src/{tag}",
+        IssueURL:   "{url}/issues/{id}",
+    },
+    "gitea": {
+        CommitURL:  "{url}/commit/{hash}",
+        CompareURL: "{url}/compare/{from}...{to}",
+        TagURL:     "{url}/releases/tag/{tag}",
+        IssueURL:   "{url}/issues/{id}",
+    },
+}
+
+var (
+    // Matches scp-like remotes: [user@]host:path
+    // Groups: 1=host 2=path
+    scpRe = regexp.MustCompile(`^(?:[\w.-]+@)?([\w.-]+):(.+)$`)
+)
+
+// New returns the forge for a repository whose origin remote is remote,
+// applying the overrides in c. The type is detected from the host name
+// (github.com, gitlab.com, bitbucket.org, or a host containing one of the type
+// names) unless c.Type is set. It returns nil when links are disabled, or when
+// neither the remote nor the config identify a repository URL and a forge type
+// or custom URL patterns. Only an unknown c.Type is an error.
+func New(remote string, c cfg.Forge) (*Forge, error) {
+    if c.Disable {
+        return nil, nil
+    }
+    web := c.URL
+    if web == "" && remote != "" {
+        // Remotes such as local paths have no web URL; render without links.
+        web, _ = WebURL(remote)
+    }
+    if web == "" {
+        return nil, nil
+    }
+
+    typ := c.Type
+    if typ == "" {
+        typ = detect(web)
+    }
+    f := Forge{URL: strings.TrimSuffix(web, "/")}
+    if typ != "" {
+        preset, ok := presets[typ]
+        if !ok {
+            return nil, fmt.Errorf("unknown forge type %q (expected github, gitlab, bitbucket or gitea)", typ)
+        }
+        f.CommitURL, f.CompareURL, f.TagURL, f.IssueURL = preset.CommitURL, preset.CompareURL, preset.TagURL, preset.IssueURL
+    }
+    if c.CommitURL != "" {
+        f.CommitURL = c.CommitURL
+    }
+    if c.CompareURL != "" {
+        f.CompareURL = c.CompareURL
+    }
+    if c.TagURL != "" {
+        f.TagURL = c.TagURL
+    }
+    if c.IssueURL != "" {
+        f.IssueURL = c.IssueURL
+    }
+    if f.CommitURL == "" && f.CompareURL == "" && f.TagURL == "" && f.IssueURL == "" {
+        return nil, nil
+    }
+    return &f, nil
+}
+
+// WebURL converts a git remote URL (https, ssh or scp-like) into the web URL
+// of the repository, e.g. git@github.com:owner/repo.git -> https://github.com/owner/repo.
+func WebURL(remote string) (string, error) {
+    var host, path string
+    if m := scpRe.FindStringSubmatch(remote); m != nil && !strings.Contains(remote, "://") {
+        host, path = m[1], m[2]
+    } else {
+        u, err := url.Parse(remote)
+        if err != nil {
+            return "", fmt.Errorf("parse remote %q: %w", remote, err)
+        }
+        switch u.Scheme {
+        case "http", "https", "ssh", "git", "git+ssh":
+        default:
+            return "", fmt.Errorf("remote %q has no web URL", remote)
+        }
+        host, path = u.Hostname(), u.Path
+        if u.Scheme == "http" {
+            return "http://" + host + portSuffix(u) + "/" + cleanPath(path), nil
+        }
+        if u.Scheme == "https" {
+            host += portSuffix(u)
+        }
+    }
+    if host == "" || cleanPath(path) == "" {
+        return "", fmt.Errorf("remote %q has no web URL", remote)
+    }
+    return "https://" + host + "/" + cleanPath(path), nil
+}
+
+// Commit returns the web URL of a commit, or "" when unsupported.
+func (f *Forge) Commit(hash string) string {
+    return f.expand(f.CommitURL, map[string]string{"{hash}": hash})
+}
+
+// Compare returns the web URL comparing two refs, or "" when unsupported.
+func (f *Forge) Compare(from, to string) string {
+    return f.expand(f.CompareURL, map[string]string{"{from}": from, "{to}": to})
+}
+
+// Tag returns the web URL of the release or tag page of tag, or "" when
+// unsupported.
+func (f *Forge) Tag(tag string) string {
+    return f.expand(f.TagURL, map[string]string{"{tag}": tag})
+}
+
+// Issue returns the web URL of an issue or pull request, or "" when unsupported.
+func (f *Forge) Issue(id string) string {
+    return f.expand(f.IssueURL, map[string]string{"{id}": strings.TrimPrefix(id, "#")})
+}
+
+// RepoIssue returns the web URL of issue id of another repository, "org/repo",
+// on the same host, or "" when unsupported.
+func (f *Forge) RepoIssue(repo, id string) string {
+    if f == nil || f.IssueURL == "" {
+        return ""
+    }
+    u, err := url.Parse(f.URL)
+    if err != nil || u.Host == "" {
+        return ""
+    }
+    u.Path = "/" + strings.Trim(repo, "/")
+    other := *f
+    other.URL = u.String()
+    return other.Issue(id)
+}
+
+func (f *Forge) expand(pattern string, values map[string]string) string {
+    if f == nil || pattern == "" {
+        return ""
+    }
+    out := strings.ReplaceAll(pattern, "{url}", f.URL)
+    for k, v := range values {
+        out = strings.ReplaceAll(out, k, v)
+    }
+    return out
+}
+
+func detect(web string) string {
+    u, err := url.Parse(web)
+    if err != nil {
+        return ""
+    }
+    host := strings.ToLower(u.Hostname())
+    switch host {
+    case "github.com":
+        return "github"
+    case "gitlab.com":
+        return "gitlab"
+    case "bitbucket.org":
+        return "bitbucket"
+    }
+    for _, typ := range []string{"github", "gitlab", "bitbucket", "gitea"} {
+        if strings.Contains(host, typ) {
+            return typ
+        }
+    }
+    return ""
+}
+
+func cleanPath(p string) string {
+    return strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+}
+
+func portSuffix(u *url.URL) string {
+    if p := u.Port(); p != "" {
+        return ":" + p
+    }
+    return ""
+}
@@ -0,0 +1,102 @@
+package forge
+
+import (
+	"strings"
+	"testing"
+
+	cfg "github.com/felipevolpatto/scribe/internal/config"
+)
+
+func TestWebURL(t *testing.T) {
+    tests := []struct {
+        remote string
+        want   string
+    }{
+        {"git@github.com:owner/repo.git", "https://github.com/owner/repo"},
+        {"https://github.com/owner/repo.git", "https://github.com/owner/repo"},
+        {"https://user@gitlab.com/group/sub/repo", "https://gitlab.com/group/sub/repo"},
+        {"ssh://git@git.example.com:2222/org/repo.git", "https://git.example.com/org/repo"},
+        {"http://gitea.local:3000/org/repo.git", "http://gitea.local:3000/org/repo"},
+    }
+    for _, tt := range tests {
+        got, err := WebURL(tt.remote)
+        if err != nil {
+            t.Fatalf("WebURL(%q): %v", tt.remote, err)
+        }
+        if got != tt.want {
+            t.Fatalf("WebURL(%q) = %q, want %q", tt.remote, got, tt.want)
+        }
+    }
+    if _, err := WebURL("/srv/git/repo.git"); err == nil {
+        t.Fatal("expected error for a local path remote")
+    }
+}
+
+func TestNew_Presets(t *testing.T) {
+    tests := []struct {
+        remote  string
+        commit  string
+        compare string
+        tag     string
+        issue   string
+    }{
+        {"git@github.com:o/r.git", "https://github.com/o/r/commit/abc", "https://github.com/o/r/compare/v1.0.0...v1.1.0", "https://github.com/o/r/releases/tag/v1.1.0", "https://github.com/o/r/issues/7"},
+        {"git@gitlab.com:o/r.git", "https://gitlab.com/o/r/-/commit/abc", "https://gitlab.com/o/r/-/compare/v1.0.0...v1.1.0", "https://gitlab.com/o/r/-/tags/v1.1.0", "https://gitlab.com/o/r/-/issues/7"},
+        {"git@bitbucket.org:o/r.git", "https://bitbucket.org/o/r/commits/abc", "https://bitbucket.org/o/r/branches/compare/v1.1.0%0Dv1.0.0", "https://bitbucket.org/o/r/src/v1.1.0", "https://bitbucket.org/o/r/issues/7"},
+        {"https://gitea.example.com/o/r.git", "https://gitea.example.com/o/r/commit/abc", "https://gitea.example.com/o/r/compare/v1.0.0...v1.1.0", "https://gitea.example.com/o/r/releases/tag/v1.1.0", "https://gitea.example.com/o/r/issues/7"},
+    }
+    for _, tt := range tests {
+        f, err := New(tt.remote, cfg.Forge{})
+        if err != nil || f == nil {
+            t.Fatalf("New(%q): %v %v", tt.remote, f, err)
+        }
+        if got := f.Commit("abc"); got != tt.commit {
+            t.Fatalf("%s commit: got %q", tt.remote, got)
+        }
+        if got := f.Compare("v1.0.0", "v1.1.0"); got != tt.compare {
+            t.Fatalf("%s compare: got %q", tt.remote, got)
+        }
+        if got, want := f.Tag("v1.1.0"), tt.tag; got != want {
+            t.Fatalf("%s tag: got %q, want %q", tt.remote, got, want)
+        }
+        if got := f.Issue("#7"); got != tt.issue {
+            t.Fatalf("%s issue: got %q", tt.remote, got)
+        }
+        if got, want := f.RepoIssue("x/y", "#7"), strings.Replace(tt.issue, "/o/r/", "/x/y/", 1); got != want {
+            t.Fatalf("%s issue of another repository: got %q, want %q", tt.remote, got, want)
+        }
+    }
+}
+
+func TestNew_SelfHostedAndDisabled(t *testing.T) {
+    f, err := New("git@code.internal:team/app.git", cfg.Forge{})
+    if err != nil || f != nil {
+        t.Fatalf("expected no forge for an unknown host, got %v %v", f, err)
+    }
+
+    f, err = New("git@code.internal:team/app.git", cfg.Forge{
+        CommitURL: "{url}/changeset/{hash}",
+        IssueURL:  "https://tracker.internal/browse/{id}",
+    })
+    if err != nil || f == nil {
+        t.Fatalf("expected custom forge, got %v %v", f, err)
+    }
+    if got := f.Commit("abc"); got != "https://code.internal/team/app/changeset/abc" {
+        t.Fatalf("custom commit url: %q", got)
+    }
+    if got := f.Compare("a", "b"); got != "" {
+        t.Fatalf("expected no compare url, got %q", got)
+    }
+
+    f, err = New("", cfg.Forge{Type: "gitea", URL: "https://git.example.com/org/repo/"})
+    if err != nil || f.Commit("abc") != "https://git.example.com/org/repo/commit/abc" {
+        t.Fatalf("explicit url and type: %v %v", f, err)
+    }
+
+    if f, _ := New("git@github.com:o/r.git", cfg.Forge{Disable: true}); f != nil {
+        t.Fatal("expected links to be disabled")
+    }
+    if _, err := New("git@github.com:o/r.git", cfg.Forge{Type: "sourcehut"}); err == nil {
+        t.Fatal("expected error for unknown forge type")
+    }
+}
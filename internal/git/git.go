@@ -2,131 +2,290 @@ package git
 
 import (
 	"errors"
-	"strconv"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	gitv5 "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/plumbing/storer"
+
+	"github.com/felipevolpatto/scribe/internal/semver"
 )
 
 // RawCommit represents a single, unprocessed commit from the git history.
+// Names and emails are resolved through the repository's .mailmap.
 type RawCommit struct {
-    Hash    string
-    Message string
+    Hash           string
+    Message        string
+    AuthorName     string
+    AuthorEmail    string
+    AuthorDate     time.Time
+    CommitterName  string
+    CommitterEmail string
+    CommitterDate  time.Time
+    // CoAuthors are the people named by "Co-authored-by:" trailers.
+    CoAuthors []Person
+    // IsMerge is set for commits with more than one parent.
+    IsMerge bool
 }
 
-// GetLatestTag returns the most recent git tag from the repository.
-func GetLatestTag(repoPath string) (string, error) {
-    repo, err := gitv5.PlainOpen(repoPath)
+// WalkOptions select which commits of a range GetCommitsWith returns.
+type WalkOptions struct {
+    // FirstParent follows only the first parent of merge commits, like
+    // 'git log --first-parent': the commits made on the branch itself and
+    // the merges into it, without the commits of the merged branches.
+    FirstParent bool
+    // OnlyMerges keeps only merge commits; SkipMerges drops them.
+    OnlyMerges bool
+    SkipMerges bool
+}
+
+// Matches a "Co-authored-by: Name <email>" trailer.
+// Groups: 1=name 2=email
+var coAuthorRe = regexp.MustCompile(`(?im)^co-authored-by:\s*(.*?)\s*<([^>]*)>\s*$`)
+
+// CoAuthors returns the people named by the "Co-authored-by:" trailers of a
+// commit message.
+func CoAuthors(message string) []Person {
+    var people []Person
+    for _, m := range coAuthorRe.FindAllStringSubmatch(message, -1) {
+        people = append(people, Person{Name: m[1], Email: m[2]})
+    }
+    return people
+}
+
+// Tag is a release tag whose name parses as a semantic version. Date is the
+// tagger date for annotated tags and the commit date for lightweight ones.
+type Tag struct {
+    Name    string
+    Version semver.Version
+    Commit  string
+    Date    time.Time
+}
+
+// GetLatestTag returns the release tag with the highest semantic version among
+// the tags reachable from HEAD that match prefix and pattern. See GetVersionTags.
+func GetLatestTag(repoPath, prefix, pattern string) (string, error) {
+    tags, err := GetVersionTags(repoPath, prefix, pattern)
     if err != nil {
         return "", err
     }
+    if len(tags) == 0 {
+        return "", errors.New("no tags found")
+    }
+    return tags[len(tags)-1].Name, nil
+}
+
+// GetVersionTags returns the tags that point at a commit reachable from HEAD,
+// match the glob pattern (path.Match syntax; empty matches every tag) and
+// parse as semantic versions once prefix is removed (e.g. "api/" for
+// "api/v1.4.0"), sorted from the lowest version to the highest.
+func GetVersionTags(repoPath, prefix, pattern string) ([]Tag, error) {
+    return GetVersionTagsAt(repoPath, "HEAD", prefix, pattern)
+}
+
+// GetVersionTagsAt is like GetVersionTags but only considers tags reachable
+// from the given revision instead of HEAD.
+func GetVersionTagsAt(repoPath, rev, prefix, pattern string) ([]Tag, error) {
+    repo, err := gitv5.PlainOpen(repoPath)
+    if err != nil {
+        return nil, err
+    }
+    at, err := resolve(repo, rev)
+    if err != nil {
+        return nil, err
+    }
+    reachable, err := reachableFrom(repo, at)
+    if err != nil {
+        return nil, err
+    }
+
     tagsIter, err := repo.Tags()
     if err != nil {
-        return "", err
+        return nil, err
     }
-    var latest string
-    var latestTime time.Time
-    _ = tagsIter.ForEach(func(ref *plumbing.Reference) error {
-        var commitHash plumbing.Hash
-        // Try annotated tag
-        if obj, err := repo.TagObject(ref.Hash()); err == nil {
-            commitHash = obj.Target
-        } else {
-            // Lightweight tag points directly to the commit
-            commitHash = ref.Hash()
-        }
-        if commit, err := repo.CommitObject(commitHash); err == nil {
-            candidate := ref.Name().Short()
-            commitTime := commit.Committer.When
-            if commitTime.After(latestTime) || (commitTime.Equal(latestTime) && semverGreater(candidate, latest)) {
-                latestTime = commitTime
-                latest = candidate
+    var tags []Tag
+    err = tagsIter.ForEach(func(ref *plumbing.Reference) error {
+        name := ref.Name().Short()
+        if pattern != "" {
+            ok, err := path.Match(pattern, name)
+            if err != nil {
+                return fmt.Errorf("tag pattern %q: %w", pattern, err)
+            }
+            if !ok {
+                return nil
             }
         }
+        if !strings.HasPrefix(name, prefix) {
+            return nil
+        }
+        version, err := semver.Parse(strings.TrimPrefix(name, prefix))
+        if err != nil {
+            return nil
+        }
+        commit, err := peelToCommit(repo, ref.Hash())
+        if err != nil || !reachable[commit.Hash] {
+            return nil
+        }
+        date := commit.Committer.When
+        if obj, err := repo.TagObject(ref.Hash()); err == nil {
+            date = obj.Tagger.When
+        }
+        tags = append(tags, Tag{Name: name, Version: version, Commit: commit.Hash.String(), Date: date})
         return nil
     })
-    if latest == "" {
-        return "", errors.New("no tags found")
+    if err != nil {
+        return nil, err
     }
-    return latest, nil
+    sort.SliceStable(tags, func(i, j int) bool {
+        if c := semver.Compare(tags[i].Version, tags[j].Version); c != 0 {
+            return c < 0
+        }
+        return tags[i].Name < tags[j].Name
+    })
+    return tags, nil
 }
 
-// semverGreater compares two tag strings like v1.2.3 and returns true if a > b.
-func semverGreater(a, b string) bool {
-    if a == "" {
-        return false
+// peelToCommit resolves a tag reference hash to the commit it points at,
+// following annotated tag objects.
+func peelToCommit(repo *gitv5.Repository, hash plumbing.Hash) (*object.Commit, error) {
+    if obj, err := repo.TagObject(hash); err == nil {
+        // Annotated tag
+        return obj.Commit()
     }
-    if b == "" {
-        return true
-    }
-    strip := func(s string) string {
-        if strings.HasPrefix(s, "v") || strings.HasPrefix(s, "V") {
-            return s[1:]
-        }
-        return s
-    }
-    ap := strings.Split(strip(a), ".")
-    bp := strings.Split(strip(b), ".")
-    for len(ap) < 3 {
-        ap = append(ap, "0")
-    }
-    for len(bp) < 3 {
-        bp = append(bp, "0")
+    // Lightweight tag points directly to the commit
+    return repo.CommitObject(hash)
+}
+
+// reachableFrom returns the set of commits reachable from the given commit.
+func reachableFrom(repo *gitv5.Repository, from plumbing.Hash) (map[plumbing.Hash]bool, error) {
+    cIter, err := repo.Log(&gitv5.LogOptions{From: from})
+    if err != nil {
+        return nil, err
     }
-    for i := 0; i < 3; i++ {
-        ai, _ := strconv.Atoi(ap[i])
-        bi, _ := strconv.Atoi(bp[i])
-        if ai > bi {
-            return true
-        }
-        if ai < bi {
-            return false
-        }
+    seen := map[plumbing.Hash]bool{}
+    err = cIter.ForEach(func(c *object.Commit) error {
+        seen[c.Hash] = true
+        return nil
+    })
+    if err != nil {
+        return nil, err
     }
-    return false
+    return seen, nil
 }
 
 // GetCommitsSince reads the git log and returns all commits between the 'fromRef' and HEAD.
-func GetCommitsSince(repoPath, fromRef string) ([]RawCommit, error) {
+// When paths are given, only commits touching a file matched by at least one
+// of them are returned; see MatchPath for the pattern syntax.
+func GetCommitsSince(repoPath, fromRef string, paths ...string) ([]RawCommit, error) {
+    return GetCommitsBetween(repoPath, fromRef, "HEAD", paths...)
+}
+
+// GetCommitsBetween returns the commits reachable from toRef but not from
+// fromRef, newest first, like 'git log fromRef..toRef'. Both ends accept any
+// revision git understands (tags, local and remote branches, full or short
+// hashes, HEAD~n); an empty fromRef selects the whole history and an empty
+// toRef means HEAD. Paths filter the commits as in GetCommitsSince.
+func GetCommitsBetween(repoPath, fromRef, toRef string, paths ...string) ([]RawCommit, error) {
+    return GetCommitsWith(repoPath, fromRef, toRef, WalkOptions{}, paths...)
+}
+
+// GetCommitsWith is GetCommitsBetween with the commits selected by opts.
+func GetCommitsWith(repoPath, fromRef, toRef string, opts WalkOptions, paths ...string) ([]RawCommit, error) {
     repo, err := gitv5.PlainOpen(repoPath)
     if err != nil {
         return nil, err
     }
-
-    // List all commits reachable from HEAD
-    head, err := repo.Head()
+    if toRef == "" {
+        toRef = "HEAD"
+    }
+    to, err := resolve(repo, toRef)
     if err != nil {
         return nil, err
     }
-    cIter, err := repo.Log(&gitv5.LogOptions{From: head.Hash()})
+    exclude := map[plumbing.Hash]bool{}
+    if fromRef != "" {
+        from, err := resolve(repo, fromRef)
+        if err != nil {
+            return nil, err
+        }
+        if exclude, err = reachableFrom(repo, from); err != nil {
+            return nil, err
+        }
+    }
+    mailmap, err := LoadMailmap(repoPath)
     if err != nil {
         return nil, err
     }
+
     var out []RawCommit
-    stopAt := plumbing.ZeroHash
-    if fromRef != "" {
-        ref, err := repo.Tag(fromRef)
-        if err == nil {
-            obj, err := repo.TagObject(ref.Hash())
-            if err == nil {
-                // Annotated tag
-                stopAt = obj.Target
-            } else {
-                // Lightweight tag
-                stopAt = ref.Hash()
+    visit := func(c *object.Commit) error {
+        merge := c.NumParents() > 1
+        if (opts.OnlyMerges && !merge) || (opts.SkipMerges && merge) {
+            return nil
+        }
+        if len(paths) > 0 {
+            ok, err := touchesPaths(c, paths)
+            if err != nil {
+                return err
+            }
+            if !ok {
+                return nil
             }
         }
+        author := mailmap.Resolve(Person{Name: c.Author.Name, Email: c.Author.Email})
+        committer := mailmap.Resolve(Person{Name: c.Committer.Name, Email: c.Committer.Email})
+        coAuthors := CoAuthors(c.Message)
+        for i := range coAuthors {
+            coAuthors[i] = mailmap.Resolve(coAuthors[i])
+        }
+        out = append(out, RawCommit{
+            Hash:           c.Hash.String(),
+            Message:        c.Message,
+            AuthorName:     author.Name,
+            AuthorEmail:    author.Email,
+            AuthorDate:     c.Author.When,
+            CommitterName:  committer.Name,
+            CommitterEmail: committer.Email,
+            CommitterDate:  c.Committer.When,
+            CoAuthors:      coAuthors,
+            IsMerge:        merge,
+        })
+        return nil
+    }
+
+    if opts.FirstParent {
+        c, err := repo.CommitObject(to)
+        if err != nil {
+            return nil, err
+        }
+        for !exclude[c.Hash] {
+            if err := visit(c); err != nil {
+                return nil, err
+            }
+            if c.NumParents() == 0 {
+                break
+            }
+            if c, err = c.Parent(0); err != nil {
+                return nil, err
+            }
+        }
+        return out, nil
+    }
+
+    cIter, err := repo.Log(&gitv5.LogOptions{From: to})
+    if err != nil {
+        return nil, err
     }
     err = cIter.ForEach(func(c *object.Commit) error {
-        if stopAt != plumbing.ZeroHash && c.Hash == stopAt {
-            return storer.ErrStop
+        if exclude[c.Hash] {
+            return nil
         }
-        out = append(out, RawCommit{Hash: c.Hash.String(), Message: c.Message})
-        return nil
+        return visit(c)
     })
     if err != nil {
         return nil, err
@@ -134,4 +293,103 @@ func GetCommitsSince(repoPath, fromRef string) ([]RawCommit, error) {
     return out, nil
 }
 
+// ResolveRevision returns the full hash of the commit a revision points at.
+func ResolveRevision(repoPath, rev string) (string, error) {
+    repo, err := gitv5.PlainOpen(repoPath)
+    if err != nil {
+        return "", err
+    }
+    hash, err := resolve(repo, rev)
+    if err != nil {
+        return "", err
+    }
+    return hash.String(), nil
+}
+
+// ShortHash returns the abbreviated form of a commit hash shown to people:
+// its first 7 characters.
+func ShortHash(hash string) string {
+    if len(hash) > 7 {
+        return hash[:7]
+    }
+    return hash
+}
 
+// resolve turns a revision into a commit hash, peeling annotated tags.
+func resolve(repo *gitv5.Repository, rev string) (plumbing.Hash, error) {
+    hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+    if err != nil {
+        return plumbing.ZeroHash, fmt.Errorf("cannot resolve revision %q: %w", rev, err)
+    }
+    return *hash, nil
+}
+
+// touchesPaths reports whether c changes a file matched by any of patterns,
+// compared with its first parent (or an empty tree for a root commit).
+func touchesPaths(c *object.Commit, patterns []string) (bool, error) {
+    tree, err := c.Tree()
+    if err != nil {
+        return false, err
+    }
+    var parentTree *object.Tree
+    if c.NumParents() > 0 {
+        parent, err := c.Parent(0)
+        if err != nil {
+            return false, err
+        }
+        if parentTree, err = parent.Tree(); err != nil {
+            return false, err
+        }
+    }
+    changes, err := object.DiffTree(parentTree, tree)
+    if err != nil {
+        return false, err
+    }
+    for _, ch := range changes {
+        for _, name := range []string{ch.From.Name, ch.To.Name} {
+            if name == "" {
+                continue
+            }
+            for _, p := range patterns {
+                if MatchPath(p, name) {
+                    return true, nil
+                }
+            }
+        }
+    }
+    return false, nil
+}
+
+// MatchPath reports whether the slash-separated file path lies under pattern.
+// The pattern is a path.Match glob matched against the file itself and each of
+// its parent directories, so "services/api", "services/api/**" and
+// "services/*" all match "services/api/main.go".
+func MatchPath(pattern, file string) bool {
+    pattern = strings.TrimSuffix(strings.TrimSuffix(pattern, "/**"), "/")
+    if pattern == "" || pattern == "." || pattern == "**" {
+        return true
+    }
+    for candidate := file; candidate != "." && candidate != "/"; candidate = path.Dir(candidate) {
+        if ok, _ := path.Match(pattern, candidate); ok {
+            return true
+        }
+    }
+    return false
+}
+
+// RemoteURL returns the first URL configured for the named remote.
+func RemoteURL(repoPath, name string) (string, error) {
+    repo, err := gitv5.PlainOpen(repoPath)
+    if err != nil {
+        return "", err
+    }
+    remote, err := repo.Remote(name)
+    if err != nil {
+        return "", fmt.Errorf("remote %q: %w", name, err)
+    }
+    urls := remote.Config().URLs
+    if len(urls) == 0 {
+        return "", fmt.Errorf("remote %q has no URL", name)
+    }
+    return urls[0], nil
+}
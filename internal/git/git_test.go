@@ -4,6 +4,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -41,7 +43,7 @@ func TestGit_TagAndCommitsSince(t *testing.T) {
     run("add", "c.txt")
     run("commit", "-m", "fix: add c")
 
-    tag, err := GetLatestTag(dir)
+    tag, err := GetLatestTag(dir, "", "")
     if err != nil {
         t.Fatalf("GetLatestTag: %v", err)
     }
@@ -59,3 +61,321 @@ func TestGit_TagAndCommitsSince(t *testing.T) {
 }
 
 
+
+func TestGetLatestTag_HighestReachableVersion(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    commit := func(name, msg string) {
+        if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        run("add", name)
+        run("commit", "-m", msg)
+    }
+    run("init", "-b", "main")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+
+    commit("a.txt", "chore: init")
+    run("tag", "v1.0.0")
+    commit("b.txt", "feat: b")
+    run("tag", "-a", "v1.1.0", "-m", "release 1.1.0")
+    run("tag", "v1.1.0-rc.1")
+
+    // A newer hotfix on a maintenance branch is not reachable from main.
+    run("checkout", "-b", "maint", "v1.0.0")
+    commit("c.txt", "fix: hotfix")
+    run("tag", "v1.0.1")
+    run("tag", "v9.9.9-unreachable")
+    run("checkout", "main")
+
+    // Newest commit carries a non-version tag.
+    commit("d.txt", "fix: d")
+    run("tag", "deploy-prod")
+
+    tag, err := GetLatestTag(dir, "", "")
+    if err != nil {
+        t.Fatalf("GetLatestTag: %v", err)
+    }
+    if tag != "v1.1.0" {
+        t.Fatalf("expected latest tag v1.1.0, got %s", tag)
+    }
+
+    tags, err := GetVersionTags(dir, "", "")
+    if err != nil {
+        t.Fatalf("GetVersionTags: %v", err)
+    }
+    var names []string
+    for _, tg := range tags {
+        names = append(names, tg.Name)
+    }
+    if strings.Join(names, ",") != "v1.0.0,v1.1.0-rc.1,v1.1.0" {
+        t.Fatalf("unexpected version tags: %v", names)
+    }
+
+    if _, err := GetLatestTag(dir, "", "release-*"); err == nil {
+        t.Fatal("expected no tags to match pattern release-*")
+    }
+}
+
+func TestGit_PackagePrefixAndPaths(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    commit := func(name, msg string) {
+        full := filepath.Join(dir, filepath.FromSlash(name))
+        if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.WriteFile(full, []byte(msg), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        run("add", name)
+        run("commit", "-m", msg)
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+
+    commit("api/main.go", "feat(api): init api")
+    run("tag", "api/v1.4.0")
+    commit("worker/main.go", "feat(worker): init worker")
+    run("tag", "worker/v0.9.2")
+    run("tag", "v3.0.0")
+    commit("api/handler.go", "fix(api): handler")
+    commit("worker/job.go", "fix(worker): job")
+
+    tag, err := GetLatestTag(dir, "api/", "")
+    if err != nil {
+        t.Fatalf("GetLatestTag: %v", err)
+    }
+    if tag != "api/v1.4.0" {
+        t.Fatalf("expected api/v1.4.0, got %s", tag)
+    }
+    if tag, _ := GetLatestTag(dir, "", ""); tag != "v3.0.0" {
+        t.Fatalf("expected root tag v3.0.0, got %s", tag)
+    }
+
+    commits, err := GetCommitsSince(dir, "api/v1.4.0", "api")
+    if err != nil {
+        t.Fatalf("GetCommitsSince: %v", err)
+    }
+    if len(commits) != 1 || commits[0].Message != "fix(api): handler\n" {
+        t.Fatalf("expected only the api commit, got %+v", commits)
+    }
+
+    all, err := GetCommitsSince(dir, "", "worker/**")
+    if err != nil {
+        t.Fatalf("GetCommitsSince: %v", err)
+    }
+    if len(all) != 2 {
+        t.Fatalf("expected 2 worker commits in full history, got %+v", all)
+    }
+}
+
+func TestMatchPath(t *testing.T) {
+    tests := []struct {
+        pattern string
+        file    string
+        want    bool
+    }{
+        {"services/api", "services/api/main.go", true},
+        {"services/api/**", "services/api/v1/handler.go", true},
+        {"services/*", "services/worker/job.go", true},
+        {"*.md", "README.md", true},
+        {"services/api", "services/apix/main.go", false},
+        {"services/api", "docs/services/api.md", false},
+    }
+    for _, tt := range tests {
+        if got := MatchPath(tt.pattern, tt.file); got != tt.want {
+            t.Fatalf("MatchPath(%q, %q) = %v, want %v", tt.pattern, tt.file, got, tt.want)
+        }
+    }
+}
+
+func TestGetCommitsBetween_Revisions(t *testing.T) {
+    dir := t.TempDir()
+    git := func(args ...string) string {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        out, err := cmd.CombinedOutput()
+        if err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+        return strings.TrimSpace(string(out))
+    }
+    commit := func(name, msg string) string {
+        if err := os.WriteFile(filepath.Join(dir, name), []byte(msg), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        git("add", name)
+        git("commit", "-m", msg)
+        return git("rev-parse", "HEAD")
+    }
+    subjects := func(commits []RawCommit) string {
+        var s []string
+        for _, c := range commits {
+            s = append(s, strings.TrimSpace(c.Message))
+        }
+        return strings.Join(s, ",")
+    }
+    git("init", "-b", "main")
+    git("config", "user.email", "test@example.com")
+    git("config", "user.name", "Test User")
+
+    commit("a.txt", "chore: init")
+    // A feature branch started before the release and merged after it.
+    git("checkout", "-b", "feature")
+    commit("f.txt", "feat: feature work")
+    git("checkout", "main")
+    commit("b.txt", "fix: before release")
+    git("tag", "-a", "v1.0.0", "-m", "v1.0.0")
+    git("merge", "--no-ff", "-m", "chore: merge feature", "feature")
+    sha := commit("c.txt", "fix: after release")
+    commit("d.txt", "docs: tip")
+    git("update-ref", "refs/remotes/origin/main", sha)
+
+    got, err := GetCommitsBetween(dir, "v1.0.0", "")
+    if err != nil {
+        t.Fatalf("GetCommitsBetween: %v", err)
+    }
+    if subjects(got) != "docs: tip,fix: after release,chore: merge feature,feat: feature work" {
+        t.Fatalf("unexpected commits since v1.0.0: %s", subjects(got))
+    }
+
+    for _, to := range []string{sha, sha[:8], "HEAD~1", "origin/main", "refs/remotes/origin/main"} {
+        got, err := GetCommitsBetween(dir, "v1.0.0", to)
+        if err != nil {
+            t.Fatalf("GetCommitsBetween to %q: %v", to, err)
+        }
+        if subjects(got) != "fix: after release,chore: merge feature,feat: feature work" {
+            t.Fatalf("unexpected commits up to %q: %s", to, subjects(got))
+        }
+    }
+
+    got, err = GetCommitsBetween(dir, "feature", "main")
+    if err != nil {
+        t.Fatalf("GetCommitsBetween from branch: %v", err)
+    }
+    if strings.Contains(subjects(got), "feature work") || strings.Contains(subjects(got), "chore: init") {
+        t.Fatalf("commits reachable from the start ref must be excluded: %s", subjects(got))
+    }
+
+    if _, err := GetCommitsBetween(dir, "no-such-ref", ""); err == nil || !strings.Contains(err.Error(), "no-such-ref") {
+        t.Fatalf("expected resolution error naming the ref, got %v", err)
+    }
+}
+
+func TestGetCommitsBetween_MailmapAndCoAuthors(t *testing.T) {
+    dir := t.TempDir()
+    git := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    git("init")
+    git("config", "user.email", "jane@old.example.com")
+    git("config", "user.name", "jane")
+    mailmap := "# canonical identities\nJane Doe <jane@example.com> <jane@old.example.com>\nJoe Bloggs <JOE@example.com>\n"
+    if err := os.WriteFile(filepath.Join(dir, ".mailmap"), []byte(mailmap), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    git("add", ".mailmap")
+    git("commit", "-m", "feat: add x\n\nCo-authored-by: joe <joe@example.com>\nco-authored-by: Ann <ann@example.com>")
+
+    commits, err := GetCommitsBetween(dir, "", "")
+    if err != nil {
+        t.Fatalf("GetCommitsBetween: %v", err)
+    }
+    c := commits[0]
+    if c.AuthorName != "Jane Doe" || c.AuthorEmail != "jane@example.com" || c.CommitterEmail != "jane@example.com" || c.CommitterDate.IsZero() {
+        t.Fatalf("mailmap not applied to author and committer: %+v", c)
+    }
+    want := []Person{{Name: "Joe Bloggs", Email: "joe@example.com"}, {Name: "Ann", Email: "ann@example.com"}}
+    if len(c.CoAuthors) != 2 || c.CoAuthors[0] != want[0] || c.CoAuthors[1] != want[1] {
+        t.Fatalf("unexpected co-authors %+v, want %+v", c.CoAuthors, want)
+    }
+}
+
+func TestGetCommitsWith_MergeStrategies(t *testing.T) {
+    dir := t.TempDir()
+    git := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    commit := func(name, msg string) {
+        if err := os.WriteFile(filepath.Join(dir, name), []byte(msg), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        git("add", name)
+        git("commit", "-m", msg)
+    }
+    subjects := func(commits []RawCommit) string {
+        var s []string
+        for _, c := range commits {
+            header, _, _ := strings.Cut(c.Message, "\n")
+            s = append(s, header)
+        }
+        // The order of commits from different branches depends on their dates.
+        sort.Strings(s)
+        return strings.Join(s, ",")
+    }
+    git("init", "-b", "main")
+    git("config", "user.email", "test@example.com")
+    git("config", "user.name", "Test User")
+    commit("a.txt", "chore: init")
+    git("tag", "v1.0.0")
+    git("checkout", "-b", "login")
+    commit("b.txt", "wip")
+    commit("c.txt", "fix typo")
+    git("checkout", "main")
+    commit("d.txt", "fix: direct")
+    git("merge", "--no-ff", "-m", "Merge pull request #5 from org/login\n\nfeat: add login", "login")
+
+    tests := []struct {
+        opts WalkOptions
+        want string
+    }{
+        {WalkOptions{}, "Merge pull request #5 from org/login,fix typo,fix: direct,wip"},
+        {WalkOptions{FirstParent: true}, "Merge pull request #5 from org/login,fix: direct"},
+        {WalkOptions{FirstParent: true, OnlyMerges: true}, "Merge pull request #5 from org/login"},
+        {WalkOptions{SkipMerges: true}, "fix typo,fix: direct,wip"},
+    }
+    for _, tt := range tests {
+        got, err := GetCommitsWith(dir, "v1.0.0", "", tt.opts)
+        if err != nil {
+            t.Fatalf("GetCommitsWith(%+v): %v", tt.opts, err)
+        }
+        if subjects(got) != tt.want {
+            t.Errorf("GetCommitsWith(%+v) = %s, want %s", tt.opts, subjects(got), tt.want)
+        }
+    }
+}
+
+func TestShortHash(t *testing.T) {
+    for hash, want := range map[string]string{
+        "0123456789abcdef": "0123456",
+        "0123456":          "0123456",
+        "abc":              "abc",
+    } {
+        if got := ShortHash(hash); got != want {
+            t.Errorf("ShortHash(%q) = %q, want %q", hash, got, want)
+        }
+    }
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Person is a name and email found in a commit.
+type Person struct {
+    Name  string
+    Email string
+}
+
+// Mailmap maps the names and emails recorded in commits to canonical ones,
+// as described in gitmailmap(5).
+type Mailmap struct {
+    entries []mailmapEntry
+}
+
+type mailmapEntry struct {
+    // The canonical name and email; either may be empty to keep the one
+    // from the commit.
+    name, email string
+    // The commit name and email to match; an empty name matches any name.
+    commitName, commitEmail string
+}
+
+// Matches one "Name <email>" pair of a .mailmap line.
+// Groups: 1=name (possibly empty) 2=email
+var mailmapPairRe = regexp.MustCompile(`\s*([^<#]*?)\s*<([^>]*)>`)
+
+// LoadMailmap reads the .mailmap file at the root of the repository. A
+// missing file yields an empty mailmap.
+func LoadMailmap(repoPath string) (*Mailmap, error) {
+    f, err := os.Open(filepath.Join(repoPath, ".mailmap"))
+    if os.IsNotExist(err) {
+        return &Mailmap{}, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+    m := &Mailmap{}
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        m.add(scanner.Text())
+    }
+    return m, scanner.Err()
+}
+
+// add records a line of one of the forms
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func (m *Mailmap) add(line string) {
+    if strings.HasPrefix(strings.TrimSpace(line), "#") {
+        return
+    }
+    pairs := mailmapPairRe.FindAllStringSubmatch(line, 2)
+    switch len(pairs) {
+    case 1:
+        if pairs[0][1] != "" {
+            m.entries = append(m.entries, mailmapEntry{name: pairs[0][1], commitEmail: pairs[0][2]})
+        }
+    case 2:
+        m.entries = append(m.entries, mailmapEntry{
+            name:        pairs[0][1],
+            email:       pairs[0][2],
+            commitName:  pairs[1][1],
+            commitEmail: pairs[1][2],
+        })
+    }
+}
+
+// Resolve returns the canonical name and email of p. Emails and names are
+// compared ignoring case, and an entry naming the commit name wins over one
+// matching the email alone.
+func (m *Mailmap) Resolve(p Person) Person {
+    if m == nil {
+        return p
+    }
+    var match *mailmapEntry
+    for i := range m.entries {
+        e := &m.entries[i]
+        if !strings.EqualFold(e.commitEmail, p.Email) {
+            continue
+        }
+        if e.commitName == "" && match == nil {
+            match = e
+        } else if e.commitName != "" && strings.EqualFold(e.commitName, p.Name) {
+            match = e
+            break
+        }
+    }
+    if match == nil {
+        return p
+    }
+    if match.name != "" {
+        p.Name = match.name
+    }
+    if match.email != "" {
+        p.Email = match.email
+    }
+    return p
+}
@@ -1,10 +1,12 @@
 package integration
 
 import (
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -62,3 +64,787 @@ func TestReleaseCommand_GeneratesChangelogAndTag(t *testing.T) {
 }
 
 
+
+func TestReleaseCommand_BumpAuto(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+
+    if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    run("add", "a.txt")
+    run("commit", "-m", "chore: init")
+    run("tag", "v1.2.3")
+
+    if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    run("add", "b.txt")
+    run("commit", "-m", "feat: add feature")
+
+    moduleRoot := filepath.Join("..", "..")
+    next := exec.Command("go", "run", "./cmd/scribe", "next-version", "--path", dir)
+    next.Dir = moduleRoot
+    out, err := next.Output()
+    if err != nil {
+        t.Fatalf("next-version run failed: %v", err)
+    }
+    if strings.TrimSpace(string(out)) != "1.3.0" {
+        t.Fatalf("expected next version 1.3.0, got %q", string(out))
+    }
+
+    cmd := exec.Command("go", "run", "./cmd/scribe", "release", "--bump", "auto", "--no-interactive", "--path", dir)
+    cmd.Dir = moduleRoot
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("release run failed: %v: %s", err, string(out))
+    }
+    tagList := exec.Command("git", "tag", "--list", "v1.3.0")
+    tagList.Dir = dir
+    if out, err := tagList.CombinedOutput(); err != nil || string(out) == "" {
+        t.Fatalf("expected tag v1.3.0, got err=%v out=%q", err, string(out))
+    }
+}
+
+func TestNextVersion_ZeroMajor(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    run("commit", "--allow-empty", "-m", "chore: init")
+    run("tag", "v0.4.1")
+    run("commit", "--allow-empty", "-m", "feat!: drop the v1 API")
+
+    moduleRoot := filepath.Join("..", "..")
+    for _, tt := range []struct{ bump, want string }{{"auto", "0.5.0"}, {"major", "1.0.0"}} {
+        next := exec.Command("go", "run", "./cmd/scribe", "next-version", "--bump", tt.bump, "--path", dir)
+        next.Dir = moduleRoot
+        out, err := next.Output()
+        if err != nil {
+            t.Fatalf("next-version --bump %s failed: %v", tt.bump, err)
+        }
+        if strings.TrimSpace(string(out)) != tt.want {
+            t.Fatalf("next-version --bump %s: expected %s, got %q", tt.bump, tt.want, string(out))
+        }
+    }
+
+    cmd := exec.Command("go", "run", "./cmd/scribe", "release", "--bump", "major", "--no-interactive", "--path", dir)
+    cmd.Dir = moduleRoot
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("release run failed: %v: %s", err, string(out))
+    }
+    tagList := exec.Command("git", "tag", "--list", "v1.0.0")
+    tagList.Dir = dir
+    if out, err := tagList.CombinedOutput(); err != nil || string(out) == "" {
+        t.Fatalf("expected tag v1.0.0, got err=%v out=%q", err, string(out))
+    }
+}
+
+func TestReleaseCommand_Package(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    write := func(name, content string) {
+        full := filepath.Join(dir, filepath.FromSlash(name))
+        if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+
+    write(".scribe.yml", "packages:\n  - { name: api, path: services/api }\n  - { name: worker, path: services/worker }\n")
+    write("services/api/main.go", "package main")
+    write("services/worker/main.go", "package main")
+    run("add", ".")
+    run("commit", "-m", "chore: init")
+    run("tag", "api/v1.4.0")
+    run("tag", "worker/v0.9.2")
+
+    write("services/api/handler.go", "package main")
+    run("add", ".")
+    run("commit", "-m", "fix(api): handle empty body")
+    write("services/worker/job.go", "package main")
+    run("add", ".")
+    run("commit", "-m", "feat(worker): add retry job")
+
+    moduleRoot := filepath.Join("..", "..")
+    cmd := exec.Command("go", "run", "./cmd/scribe", "release", "--package", "api", "--bump", "auto", "--no-interactive", "--path", dir)
+    cmd.Dir = moduleRoot
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("release run failed: %v: %s", err, string(out))
+    }
+
+    b, err := os.ReadFile(filepath.Join(dir, "services", "api", "CHANGELOG.md"))
+    if err != nil {
+        t.Fatalf("package CHANGELOG.md missing: %v", err)
+    }
+    if !strings.Contains(string(b), "## v1.4.1") || !strings.Contains(string(b), "handle empty body") {
+        t.Fatalf("unexpected package changelog: %q", string(b))
+    }
+    if strings.Contains(string(b), "retry job") {
+        t.Fatalf("package changelog contains another package's commit: %q", string(b))
+    }
+    if _, err := os.Stat(filepath.Join(dir, "CHANGELOG.md")); err == nil {
+        t.Fatal("root CHANGELOG.md should not be written for a package release")
+    }
+    tagList := exec.Command("git", "tag", "--list", "api/v1.4.1")
+    tagList.Dir = dir
+    if out, err := tagList.CombinedOutput(); err != nil || string(out) == "" {
+        t.Fatalf("expected tag api/v1.4.1, got err=%v out=%q", err, string(out))
+    }
+}
+
+func TestHistoryCommand_WritesAllReleases(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    commit := func(name, msg string) {
+        if err := os.WriteFile(filepath.Join(dir, name), []byte(msg), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        run("add", name)
+        run("commit", "-m", msg)
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+
+    commit("a.txt", "feat: first feature")
+    run("tag", "v0.1.0")
+    commit("b.txt", "fix: first fix")
+    run("tag", "v0.1.1")
+    commit("c.txt", "feat: second feature")
+    run("tag", "v0.10.0")
+    commit("d.txt", "feat: unreleased")
+
+    moduleRoot := filepath.Join("..", "..")
+    cmd := exec.Command("go", "run", "./cmd/scribe", "history", "--path", dir)
+    cmd.Dir = moduleRoot
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("history run failed: %v: %s", err, string(out))
+    }
+    b, err := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+    if err != nil {
+        t.Fatalf("CHANGELOG.md missing: %v", err)
+    }
+    content := string(b)
+    i10 := strings.Index(content, "## v0.10.0")
+    i11 := strings.Index(content, "## v0.1.1")
+    i01 := strings.Index(content, "## v0.1.0")
+    if i10 < 0 || i11 < 0 || i01 < 0 || !(i10 < i11 && i11 < i01) {
+        t.Fatalf("expected releases newest first, got %q", content)
+    }
+    if !strings.Contains(content[i11:i01], "first fix") || strings.Contains(content[i11:i01], "feature") {
+        t.Fatalf("v0.1.1 should only contain its own commits: %q", content[i11:i01])
+    }
+    if strings.Contains(content, "unreleased") {
+        t.Fatalf("commits after the last tag must not be included: %q", content)
+    }
+
+    again := exec.Command("go", "run", "./cmd/scribe", "history", "--path", dir)
+    again.Dir = moduleRoot
+    if err := again.Run(); err == nil {
+        t.Fatal("expected history to refuse overwriting an existing changelog without --force")
+    }
+}
+
+func TestHistoryCommand_Layout(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    run("commit", "--allow-empty", "-m", "feat: first feature")
+    run("tag", "v0.1.0")
+    run("commit", "--allow-empty", "-m", "chore: tidy up")
+    run("tag", "v0.1.1")
+
+    moduleRoot := filepath.Join("..", "..")
+    cmd := exec.Command("go", "run", "./cmd/scribe", "history", "--path", dir)
+    cmd.Dir = moduleRoot
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("history run failed: %v: %s", err, string(out))
+    }
+    b, err := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    content := string(b)
+    if !strings.HasPrefix(content, "# Changelog\n\n## v0.1.1 - ") {
+        t.Fatalf("expected the title before the newest release:\n%s", content)
+    }
+    if !strings.Contains(content, "\n\nNo notable changes.\n\n## v0.1.0 - ") {
+        t.Fatalf("expected a no changes line for the empty release, one blank line apart from the next:\n%s", content)
+    }
+    if strings.Contains(content, "\n\n\n") || !strings.HasSuffix(content, ")\n") {
+        t.Fatalf("expected releases separated by a single blank line:\n%q", content)
+    }
+}
+
+func TestHistoryCommand_NewContributors(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte("contributors:\n  list: true\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    run("add", ".scribe.yml")
+    run("commit", "-m", "feat: first feature", "--author", "Jane <jane@example.com>")
+    run("tag", "v0.1.0")
+    run("commit", "--allow-empty", "-m", "fix: first fix", "--author", "Jane <jane@example.com>")
+    run("commit", "--allow-empty", "-m", "fix: second fix", "--author", "Joe <joe@example.com>")
+    run("tag", "v0.2.0")
+
+    cmd := exec.Command("go", "run", "./cmd/scribe", "history", "--path", dir)
+    cmd.Dir = filepath.Join("..", "..")
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("history run failed: %v: %s", err, string(out))
+    }
+    b, err := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    content := string(b)
+    i01 := strings.Index(content, "## v0.1.0")
+    if i01 < 0 {
+        t.Fatalf("missing v0.1.0:\n%s", content)
+    }
+    latest, first := content[:i01], content[i01:]
+    if !strings.Contains(latest, "### New Contributors\n* Joe made") || strings.Contains(latest, "* Jane made") {
+        t.Fatalf("v0.2.0 should only welcome Joe:\n%s", latest)
+    }
+    if !strings.Contains(first, "### New Contributors\n* Jane made") {
+        t.Fatalf("v0.1.0 should welcome Jane:\n%s", first)
+    }
+}
+
+func TestHistoryCommand_PackageNewContributors(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    write := func(name, content string) {
+        path := filepath.Join(dir, filepath.FromSlash(name))
+        if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    write(".scribe.yml", "contributors:\n  list: true\npackages:\n  - { name: api, path: services/api }\n  - { name: worker, path: services/worker }\n")
+    write("services/api/main.go", "package main")
+    run("add", ".")
+    run("commit", "-m", "feat: api", "--author", "Jane <jane@example.com>")
+    write("services/worker/main.go", "package main")
+    run("add", ".")
+    run("commit", "-m", "feat: worker", "--author", "Joe <joe@example.com>")
+    run("tag", "api/v0.1.0")
+    write("services/api/handler.go", "package main")
+    run("add", ".")
+    run("commit", "-m", "fix: api fix", "--author", "Joe <joe@example.com>")
+    run("tag", "api/v0.2.0")
+
+    cmd := exec.Command("go", "run", "./cmd/scribe", "history", "--package", "api", "--path", dir)
+    cmd.Dir = filepath.Join("..", "..")
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("history run failed: %v: %s", err, string(out))
+    }
+    b, err := os.ReadFile(filepath.Join(dir, "services", "api", "CHANGELOG.md"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    content := string(b)
+    i01 := strings.Index(content, "## v0.1.0")
+    if i01 < 0 {
+        t.Fatalf("missing v0.1.0:\n%s", content)
+    }
+    if !strings.Contains(content[:i01], "### New Contributors\n* Joe made") {
+        t.Fatalf("v0.2.0 should welcome Joe, whose earlier commit is outside the package:\n%s", content)
+    }
+}
+
+func TestNewCommand_ToRefTag(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    run("commit", "--allow-empty", "-m", "feat: first feature")
+    run("tag", "v1.0.0")
+    run("commit", "--allow-empty", "-m", "fix: first fix")
+    run("tag", "v1.0.1")
+    run("commit", "--allow-empty", "-m", "feat: unreleased")
+
+    moduleRoot := filepath.Join("..", "..")
+    cmd := exec.Command("go", "run", "./cmd/scribe", "new", "--to-ref", "v1.0.1", "--no-interactive", "--path", dir)
+    cmd.Dir = moduleRoot
+    out, err := cmd.Output()
+    if err != nil {
+        t.Fatalf("new run failed: %v", err)
+    }
+    if !strings.HasPrefix(string(out), "## v1.0.1 - ") || !strings.Contains(string(out), "* first fix") || strings.Contains(string(out), "unreleased") {
+        t.Fatalf("expected the notes of v1.0.1 with its heading, got %q", string(out))
+    }
+
+    cmd = exec.Command("go", "run", "./cmd/scribe", "new", "--to-ref", "HEAD", "--no-interactive", "--path", dir)
+    cmd.Dir = moduleRoot
+    if out, err = cmd.Output(); err != nil {
+        t.Fatalf("new run failed: %v", err)
+    }
+    if strings.HasPrefix(string(out), "## ") {
+        t.Fatalf("unreleased changes must not get a release heading, got %q", string(out))
+    }
+}
+
+func TestNewCommand_JSONFormat(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    run("add", "a.txt")
+    run("commit", "-m", "feat(api): add feature")
+
+    moduleRoot := filepath.Join("..", "..")
+    cmd := exec.Command("go", "run", "./cmd/scribe", "new", "--format", "json", "--no-interactive", "--path", dir)
+    cmd.Dir = moduleRoot
+    out, err := cmd.Output()
+    if err != nil {
+        t.Fatalf("new run failed: %v", err)
+    }
+    var release struct {
+        SchemaVersion int `json:"schema_version"`
+        Sections      []struct {
+            Items []struct {
+                Scope  string `json:"scope"`
+                Author struct {
+                    Email string `json:"email"`
+                } `json:"author"`
+            } `json:"items"`
+        } `json:"sections"`
+    }
+    if err := json.Unmarshal(out, &release); err != nil {
+        t.Fatalf("output is not JSON: %v: %s", err, string(out))
+    }
+    if release.SchemaVersion != 1 || len(release.Sections) != 1 || release.Sections[0].Items[0].Scope != "api" {
+        t.Fatalf("unexpected release: %s", string(out))
+    }
+    if release.Sections[0].Items[0].Author.Email != "test@example.com" {
+        t.Fatalf("expected author email in output: %s", string(out))
+    }
+}
+
+func TestReleaseCommand_AnnotatedTag(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) string {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        out, err := cmd.CombinedOutput()
+        if err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+        return strings.TrimSpace(string(out))
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte("release:\n  tag_message: \"Release {{ .Tag }}\\n\\n{{ .Notes }}\"\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    run("add", ".scribe.yml")
+    run("commit", "-m", "feat: add feature")
+
+    cmd := exec.Command("go", "run", "./cmd/scribe", "release", "v1.0.0", "--annotate", "--no-interactive", "--path", dir)
+    cmd.Dir = filepath.Join("..", "..")
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("release run failed: %v: %s", err, string(out))
+    }
+    if got := run("cat-file", "-t", "v1.0.0"); got != "tag" {
+        t.Fatalf("expected an annotated tag, got %q", got)
+    }
+    msg := run("tag", "-l", "--format=%(contents)", "v1.0.0")
+    if !strings.HasPrefix(msg, "Release v1.0.0\n\n## v1.0.0 - ") || !strings.Contains(msg, "* add feature") {
+        t.Fatalf("unexpected tag message:\n%s", msg)
+    }
+}
+
+func TestReleaseCommand_Push(t *testing.T) {
+    dir := t.TempDir()
+    bare := filepath.Join(t.TempDir(), "remote.git")
+    run := func(args ...string) string {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        out, err := cmd.CombinedOutput()
+        if err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+        return strings.TrimSpace(string(out))
+    }
+    run("init", "-b", "main")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    run("init", "--bare", "-b", "main", bare)
+    run("remote", "add", "upstream", bare)
+    run("commit", "--allow-empty", "-m", "feat: add feature")
+
+    cmd := exec.Command("go", "run", "./cmd/scribe", "release", "v1.0.0", "--push", "--remote", "upstream", "--no-interactive", "--path", dir)
+    cmd.Dir = filepath.Join("..", "..")
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("release run failed: %v: %s", err, string(out))
+    }
+    head := run("rev-parse", "HEAD")
+    if got := run("--git-dir", bare, "rev-parse", "main", "v1.0.0^{commit}"); got != head+"\n"+head {
+        t.Fatalf("expected main and v1.0.0 on the remote at %s, got %q", head, got)
+    }
+}
+
+func TestReleaseCommand_KeepAChangelogPreset(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    run("remote", "add", "origin", "git@github.com:acme/widget.git")
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte("preset: keepachangelog\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    existing := "# Changelog\n\nAll notable changes to this project will be documented in this file.\n\n" +
+        "## [Unreleased]\n\n## [1.0.0] - 2024-01-01\n\n### Added\n\n- first\n\n" +
+        "[Unreleased]: https://github.com/acme/widget/compare/v1.0.0...HEAD\n[1.0.0]: https://github.com/acme/widget/releases/tag/v1.0.0\n"
+    if err := os.WriteFile(filepath.Join(dir, "CHANGELOG.md"), []byte(existing), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    run("add", ".")
+    run("commit", "-m", "chore: init")
+    run("tag", "v1.0.0")
+    run("commit", "--allow-empty", "-m", "feat: add widgets")
+    run("commit", "--allow-empty", "-m", "fix: stop leaking")
+
+    cmd := exec.Command("go", "run", "./cmd/scribe", "release", "1.1.0", "--no-interactive", "--path", dir)
+    cmd.Dir = filepath.Join("..", "..")
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("release run failed: %v: %s", err, string(out))
+    }
+    b, err := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    got := string(b)
+    for _, want := range []string{
+        "# Changelog\n\nAll notable changes to this project will be documented in this file.\n\n## [Unreleased]\n\n## [1.1.0] - ",
+        "### Added\n\n- add widgets ([",
+        "### Fixed\n\n- stop leaking ([",
+        "\n\n## [1.0.0] - 2024-01-01\n",
+        "\n\n[Unreleased]: https://github.com/acme/widget/compare/v1.1.0...HEAD\n" +
+            "[1.1.0]: https://github.com/acme/widget/compare/v1.0.0...v1.1.0\n" +
+            "[1.0.0]: https://github.com/acme/widget/releases/tag/v1.0.0\n",
+    } {
+        if !strings.Contains(got, want) {
+            t.Fatalf("expected %q in changelog:\n%s", want, got)
+        }
+    }
+    if strings.Count(got, "## [Unreleased]") != 1 {
+        t.Fatalf("expected the Unreleased heading to be kept once, emptied:\n%s", got)
+    }
+}
+
+func TestKeepAChangelogPreset_FirstReleaseLink(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    run("remote", "add", "origin", "git@github.com:acme/widget.git")
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte("preset: keepachangelog\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    run("add", ".scribe.yml")
+    run("commit", "-m", "feat: first feature")
+
+    moduleRoot := filepath.Join("..", "..")
+    cmd := exec.Command("go", "run", "./cmd/scribe", "release", "v1.0.0", "--no-interactive", "--path", dir)
+    cmd.Dir = moduleRoot
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("release run failed: %v: %s", err, string(out))
+    }
+    b, err := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(string(b), "## [1.0.0] - ") || !strings.HasSuffix(string(b), "\n[1.0.0]: https://github.com/acme/widget/releases/tag/v1.0.0\n") {
+        t.Fatalf("expected a tag link for the first release:\n%s", b)
+    }
+
+    run("commit", "--allow-empty", "-m", "fix: first fix")
+    run("tag", "v1.0.1")
+    history := exec.Command("go", "run", "./cmd/scribe", "history", "--force", "--path", dir)
+    history.Dir = moduleRoot
+    if out, err := history.CombinedOutput(); err != nil {
+        t.Fatalf("history run failed: %v: %s", err, string(out))
+    }
+    if b, err = os.ReadFile(filepath.Join(dir, "CHANGELOG.md")); err != nil {
+        t.Fatal(err)
+    }
+    if !strings.HasPrefix(string(b), "# Changelog\n\nAll notable changes to this project will be documented in this file.\n\n") {
+        t.Fatalf("expected the Keep a Changelog preamble:\n%s", b)
+    }
+    want := "\n[1.0.1]: https://github.com/acme/widget/compare/v1.0.0...v1.0.1\n[1.0.0]: https://github.com/acme/widget/releases/tag/v1.0.0\n"
+    if !strings.HasSuffix(string(b), want) {
+        t.Fatalf("expected link definitions for every release:\n%s", b)
+    }
+}
+
+func TestLintCommand(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte("scopes: [api]\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    run("add", ".scribe.yml")
+    run("commit", "-m", "chore: init")
+    run("tag", "v1.0.0")
+    run("commit", "--allow-empty", "-m", "feat(api): add endpoint")
+    run("commit", "--allow-empty", "-m", "update stuff")
+    run("commit", "--allow-empty", "-m", "fix(db): close pool")
+
+    moduleRoot := filepath.Join("..", "..")
+    cmd := exec.Command("go", "run", "./cmd/scribe", "lint", "--path", dir, "--from-ref", "v1.0.0")
+    cmd.Dir = moduleRoot
+    out, err := cmd.CombinedOutput()
+    if err == nil {
+        t.Fatalf("expected lint to fail:\n%s", out)
+    }
+    for _, want := range []string{": update stuff\n  [header-format]", ": fix(db): close pool\n  [scope-enum] scope \"db\" is not allowed (allowed: api)", "2 of 3 commit message(s) failed lint"} {
+        if !strings.Contains(string(out), want) {
+            t.Fatalf("expected %q in lint output:\n%s", want, out)
+        }
+    }
+
+    cmd = exec.Command("go", "run", "./cmd/scribe", "lint", "--path", dir)
+    cmd.Dir = moduleRoot
+    cmd.Stdin = strings.NewReader("feat(api): add endpoint\n")
+    if out, err := cmd.CombinedOutput(); err != nil {
+        t.Fatalf("expected a valid message from stdin to pass: %v: %s", err, out)
+    }
+}
+
+func TestNewCommand_ReportsSkippedCommits(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    run("init")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte("ignore_scopes: [deps]\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    run("add", ".scribe.yml")
+    run("commit", "-m", "feat: add feature")
+    run("commit", "--allow-empty", "-m", "chore(deps): bump x")
+
+    moduleRoot := filepath.Join("..", "..")
+    newCmd := func(args ...string) (string, string, error) {
+        cmd := exec.Command("go", append([]string{"run", "./cmd/scribe", "new", "--no-interactive", "--path", dir}, args...)...)
+        cmd.Dir = moduleRoot
+        var stdout, stderr strings.Builder
+        cmd.Stdout, cmd.Stderr = &stdout, &stderr
+        err := cmd.Run()
+        return stdout.String(), stderr.String(), err
+    }
+
+    _, stderr, err := newCmd("--fail-on-skipped")
+    if err != nil {
+        t.Fatalf("commits excluded by ignore_scopes must not fail: %v: %s", err, stderr)
+    }
+    if !strings.Contains(stderr, "Skipped 1 commit(s)") || !strings.Contains(stderr, `chore(deps): bump x (scope "deps" is in ignore_scopes)`) {
+        t.Fatalf("expected the ignored commit in the report:\n%s", stderr)
+    }
+
+    run("commit", "--allow-empty", "-m", "WIP stuff")
+    stdout, stderr, err := newCmd()
+    if err != nil {
+        t.Fatalf("new failed: %v: %s", err, stderr)
+    }
+    if !strings.Contains(stderr, "WIP stuff (commit message does not follow Conventional Commits)") || !strings.Contains(stdout, "add feature") {
+        t.Fatalf("unexpected output:\nstdout: %s\nstderr: %s", stdout, stderr)
+    }
+    if _, stderr, err := newCmd("--fail-on-skipped"); err == nil || !strings.Contains(stderr, "1 commit(s) are not Conventional Commits") {
+        t.Fatalf("expected --fail-on-skipped to fail: %v: %s", err, stderr)
+    }
+}
+
+func TestNewCommand_MergeStrategy(t *testing.T) {
+    dir := t.TempDir()
+    run := func(args ...string) {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+    }
+    run("init", "-b", "main")
+    run("config", "user.email", "test@example.com")
+    run("config", "user.name", "Test User")
+    if err := os.WriteFile(filepath.Join(dir, ".scribe.yml"), []byte("strategy: merges\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    run("add", ".scribe.yml")
+    run("commit", "-m", "chore: init")
+    run("tag", "v1.0.0")
+    run("checkout", "-b", "login")
+    run("commit", "--allow-empty", "-m", "feat: half of login")
+    run("checkout", "main")
+    run("commit", "--allow-empty", "-m", "fix: direct fix")
+    run("merge", "--no-ff", "-m", "Merge pull request #5 from org/login\n\nfeat: add login", "login")
+
+    cmd := exec.Command("go", "run", "./cmd/scribe", "new", "--no-interactive", "--path", dir)
+    cmd.Dir = filepath.Join("..", "..")
+    out, err := cmd.CombinedOutput()
+    if err != nil {
+        t.Fatalf("new failed: %v: %s", err, out)
+    }
+    if !strings.Contains(string(out), "* add login (#5)") || strings.Contains(string(out), "half of login") || strings.Contains(string(out), "direct fix") {
+        t.Fatalf("expected only the pull request title:\n%s", out)
+    }
+}
+
+func TestNewCommand_CancelsReverts(t *testing.T) {
+    dir := t.TempDir()
+    git := func(args ...string) string {
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        out, err := cmd.CombinedOutput()
+        if err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+        return strings.TrimSpace(string(out))
+    }
+    commit := func(name, msg string) string {
+        if err := os.WriteFile(filepath.Join(dir, name), []byte(msg), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        git("add", name)
+        git("commit", "-m", msg)
+        return git("rev-parse", "HEAD")
+    }
+    git("init")
+    git("config", "user.email", "test@example.com")
+    git("config", "user.name", "Test User")
+    old := commit("a.txt", "feat: add export")
+    git("tag", "v1.0.0")
+    login := commit("b.txt", "feat: add login")
+    commit("c.txt", "fix: handle empty input")
+    git("revert", "--no-edit", login)
+    git("revert", "--no-edit", old)
+
+    cmd := exec.Command("go", "run", "./cmd/scribe", "new", "--no-interactive", "--path", dir)
+    cmd.Dir = filepath.Join("..", "..")
+    var stdout, stderr strings.Builder
+    cmd.Stdout, cmd.Stderr = &stdout, &stderr
+    if err := cmd.Run(); err != nil {
+        t.Fatalf("new failed: %v: %s", err, stderr.String())
+    }
+    out := stdout.String()
+    if strings.Contains(out, "add login") || !strings.Contains(out, "handle empty input") {
+        t.Fatalf("expected the reverted feature to be dropped:\n%s", out)
+    }
+    if !strings.Contains(out, "### Reverts\n* feat: add export (") || !strings.Contains(out, "(reverts "+old[:7]+")") {
+        t.Fatalf("expected a Reverts entry for the earlier release:\n%s", out)
+    }
+    if !strings.Contains(stderr.String(), "reverted in this release") {
+        t.Fatalf("expected the cancelled commits in the skipped report:\n%s", stderr.String())
+    }
+}
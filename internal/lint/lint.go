@@ -0,0 +1,138 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	cfg "github.com/felipevolpatto/scribe/internal/config"
+	"github.com/felipevolpatto/scribe/internal/parser"
+)
+
+// Rules are the checks applied to a commit message besides the Conventional
+// Commits grammar itself.
+type Rules struct {
+    // Types lists the allowed commit types and their aliases; empty allows
+    // any type.
+    Types cfg.Types
+    // Scopes lists the allowed scopes; empty allows any scope.
+    Scopes cfg.Scopes
+    // RequireScope rejects headers without a scope.
+    RequireScope bool
+    // MaxHeaderLength limits the header length in characters; 0 disables it.
+    MaxHeaderLength int
+}
+
+// Problem is a single rule a commit message breaks.
+type Problem struct {
+    Rule    string
+    Message string
+}
+
+func (p Problem) String() string {
+    return fmt.Sprintf("[%s] %s", p.Rule, p.Message)
+}
+
+// RulesFromConfig returns the rules for the types and scopes declared in
+// .scribe.yml and those configured under lint.
+func RulesFromConfig(c *cfg.Config) Rules {
+    return Rules{
+        Types:           c.Types,
+        Scopes:          c.Scopes,
+        RequireScope:    c.Lint.RequireScope,
+        MaxHeaderLength: c.Lint.MaxHeaderLength,
+    }
+}
+
+var (
+    // Matches the part of a header before the colon.
+    // Groups: 1=type 2=scope with parentheses (optional) 3=scope 4=!
+    prefixRe = regexp.MustCompile(`^(\w*)(\((.*)\))?(!)?$`)
+    scopeRe  = regexp.MustCompile(`^[\w\/-]+$`)
+
+    // Messages git generates itself, which are accepted as they are.
+    generatedRe = regexp.MustCompile(`^(Merge |Revert "|(fixup|squash|amend)! )`)
+)
+
+// Message checks a commit message and returns the problems found, in the
+// order of the rules; nil means the message is valid.
+func Message(message string, rules Rules) []Problem {
+    message = strings.ReplaceAll(message, "\r\n", "\n")
+    lines := strings.Split(strings.TrimLeft(message, "\n"), "\n")
+    header := strings.TrimSpace(lines[0])
+    if header == "" {
+        return []Problem{{Rule: "header-empty", Message: "commit message is empty"}}
+    }
+    if generatedRe.MatchString(header) {
+        return nil
+    }
+
+    var problems []Problem
+    add := func(rule, format string, args ...any) {
+        problems = append(problems, Problem{Rule: rule, Message: fmt.Sprintf(format, args...)})
+    }
+    if rules.MaxHeaderLength > 0 && len([]rune(header)) > rules.MaxHeaderLength {
+        add("header-max-length", "header is %d characters long, the limit is %d", len([]rune(header)), rules.MaxHeaderLength)
+    }
+    if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+        add("body-leading-blank", "the header must be followed by a blank line")
+    }
+
+    pc, err := parser.Parse(message)
+    if err != nil {
+        return append(diagnoseHeader(header), problems...)
+    }
+    if _, ok := rules.Types.Canonical(pc.Type); len(rules.Types) > 0 && !ok {
+        add("type-enum", "type %q is not allowed (allowed: %s)", pc.Type, strings.Join(rules.Types.Names(), ", "))
+    }
+    if pc.Scope == "" && rules.RequireScope {
+        add("scope-empty", "a scope is required, e.g. \"%s(api): %s\"", pc.Type, pc.Description)
+    }
+    if pc.Scope != "" && !rules.Scopes.Allowed(pc.Scope) {
+        add("scope-enum", "scope %q is not allowed (allowed: %s)", pc.Scope, strings.Join(rules.Scopes, ", "))
+    }
+    return problems
+}
+
+// diagnoseHeader explains why a header does not follow the Conventional
+// Commits grammar "type(scope)!: description".
+func diagnoseHeader(header string) []Problem {
+    format := func(rule, msg string) []Problem {
+        return []Problem{{Rule: rule, Message: msg}}
+    }
+    prefix, rest, ok := strings.Cut(header, ":")
+    if !ok {
+        return format("header-format", fmt.Sprintf("header %q must look like \"type(scope): description\"", header))
+    }
+    m := prefixRe.FindStringSubmatch(prefix)
+    switch {
+    case m == nil:
+        return format("header-format", fmt.Sprintf("%q is not a valid type with an optional (scope) and !", prefix))
+    case m[1] == "":
+        return format("type-empty", "type is missing before the colon")
+    case m[2] != "" && !scopeRe.MatchString(m[3]):
+        return format("scope-format", fmt.Sprintf("scope %q may only contain letters, digits, _, / and -", m[3]))
+    case strings.TrimSpace(rest) == "":
+        return format("subject-empty", "description is missing after the colon")
+    case !strings.HasPrefix(rest, " "):
+        return format("header-format", "a space must follow the colon")
+    }
+    return format("header-format", fmt.Sprintf("header %q must look like \"type(scope): description\"", header))
+}
+
+// Clean prepares the contents of a commit message file the way git does
+// before recording it: lines starting with '#' and everything below the
+// scissors line are removed.
+func Clean(message string) string {
+    var out []string
+    for _, line := range strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n") {
+        if strings.HasPrefix(line, "# ") && strings.Contains(line, ">8") {
+            break
+        }
+        if strings.HasPrefix(line, "#") {
+            continue
+        }
+        out = append(out, line)
+    }
+    return strings.TrimSpace(strings.Join(out, "\n"))
+}
@@ -0,0 +1,57 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	cfg "github.com/felipevolpatto/scribe/internal/config"
+)
+
+func TestMessage(t *testing.T) {
+    rules := Rules{Types: cfg.Types{{Name: "feat", Aliases: []string{"feature"}}, {Name: "fix"}}, Scopes: []string{"api", "cli"}, MaxHeaderLength: 40}
+    tests := []struct {
+        message string
+        rules   []string
+    }{
+        {"feat(api): add endpoint", nil},
+        {"fix!: drop flag\n\nBREAKING CHANGE: gone", nil},
+        {"Merge branch 'main' into topic", nil},
+        {"fixup! feat: add endpoint", nil},
+        {"", []string{"header-empty"}},
+        {"add endpoint", []string{"header-format"}},
+        {": add endpoint", []string{"type-empty"}},
+        {"feat(a b): add endpoint", []string{"scope-format"}},
+        {"feat:", []string{"subject-empty"}},
+        {"feat:add endpoint", []string{"header-format"}},
+        {"feature: add endpoint", nil},
+        {"Fix: correct typo", nil},
+        {"wip: add endpoint", []string{"type-enum"}},
+        {"feat(db): add endpoint", []string{"scope-enum"}},
+        {"feat: add an endpoint with a very long description", []string{"header-max-length"}},
+        {"feat: add endpoint\nmore text", []string{"body-leading-blank"}},
+        {"docs(web): typo", []string{"type-enum", "scope-enum"}},
+    }
+    for _, tt := range tests {
+        var got []string
+        for _, p := range Message(tt.message, rules) {
+            got = append(got, p.Rule)
+        }
+        if strings.Join(got, ",") != strings.Join(tt.rules, ",") {
+            t.Errorf("Message(%q) broke %v, want %v", tt.message, got, tt.rules)
+        }
+    }
+}
+
+func TestMessage_RequireScope(t *testing.T) {
+    problems := Message("feat: add endpoint", Rules{RequireScope: true})
+    if len(problems) != 1 || problems[0].Rule != "scope-empty" || !strings.Contains(problems[0].Message, `"feat(api): add endpoint"`) {
+        t.Fatalf("unexpected problems: %v", problems)
+    }
+}
+
+func TestClean(t *testing.T) {
+    in := "feat: add x\n\nbody\n# Please enter the commit message\n# ------------------------ >8 ------------------------\ndiff --git a/x b/x\n"
+    if got := Clean(in); got != "feat: add x\n\nbody" {
+        t.Fatalf("unexpected cleaned message %q", got)
+    }
+}
@@ -0,0 +1,129 @@
+package markdown
+
+import (
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/felipevolpatto/scribe/internal/changelog"
+	"github.com/felipevolpatto/scribe/internal/forge"
+	gitpkg "github.com/felipevolpatto/scribe/internal/git"
+	"github.com/felipevolpatto/scribe/internal/parser"
+)
+
+// Funcs returns the helper functions available to changelog templates:
+//
+//	shortHash <hash>            first 7 characters of a commit hash
+//	title <s>                   upper-case the first letter of every word
+//	upper <s>, lower <s>        change case
+//	trim <s>                    strip surrounding whitespace
+//	join <sep> <list>           join a list of strings
+//	trimPrefix <prefix> <s>     remove a leading prefix, e.g. "v" from a version
+//	indent <prefix> <s>         prefix every non-empty line
+//	date <layout> <time>        format a time with a Go layout
+//	url <base> <segments...>    append escaped path segments to a base URL
+//	linkIssues <forge> <s>      turn "#123" references into Markdown links
+//	linkReferences <forge> <s> <refs>
+//	                            also link the item's other references, such
+//	                            as "org/repo#9" and "PROJ-123"
+//	references <refs>           list references as "closes [#45](url), ..."
+func Funcs() template.FuncMap {
+    return template.FuncMap{
+        "shortHash":      gitpkg.ShortHash,
+        "title":          titleCase,
+        "upper":          strings.ToUpper,
+        "lower":          strings.ToLower,
+        "trim":           strings.TrimSpace,
+        "join":           func(sep string, elems []string) string { return strings.Join(elems, sep) },
+        "trimPrefix":     func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+        "indent":         indent,
+        "date":           func(layout string, t time.Time) string { return t.Format(layout) },
+        "url":            buildURL,
+        "linkIssues":     linkIssues,
+        "linkReferences": linkReferences,
+        "references":     references,
+    }
+}
+
+// linkIssues replaces the "#123" references in s with links to the forge's
+// issue pages. Without a forge, or when it has no issue URL, s is returned
+// unchanged.
+func linkIssues(f *forge.Forge, s string) string {
+    return linkReferences(f, s, nil)
+}
+
+// linkReferences replaces the references of refs mentioned in s with links
+// to their URLs, and the other "#123" references like linkIssues. The
+// references are found by parser.ReplaceReferences, like those of the
+// commit.
+func linkReferences(f *forge.Forge, s string, refs []changelog.Reference) string {
+    urls := map[string]string{}
+    for _, r := range refs {
+        urls[r.String()] = r.URL
+    }
+    return parser.ReplaceReferences(s, func(r parser.IssueRef) string {
+        link, ok := urls[r.String()]
+        if !ok && r.Repo == "" && !r.IsKey() && f != nil && f.IssueURL != "" {
+            link = f.Issue(r.ID)
+        }
+        if link == "" {
+            return ""
+        }
+        return "[" + r.String() + "](" + link + ")"
+    })
+}
+
+// references lists refs separated by commas, each preceded by its action,
+// e.g. "closes [#45](url), refs PROJ-123".
+func references(refs []changelog.Reference) string {
+    parts := make([]string, len(refs))
+    for i, r := range refs {
+        text := r.String()
+        if r.URL != "" {
+            text = "[" + text + "](" + r.URL + ")"
+        }
+        if r.Action != "" {
+            text = r.Action + " " + text
+        }
+        parts[i] = text
+    }
+    return strings.Join(parts, ", ")
+}
+
+func titleCase(s string) string {
+    runes := []rune(s)
+    for i, r := range runes {
+        if i == 0 || unicode.IsSpace(runes[i-1]) {
+            runes[i] = unicode.ToTitle(r)
+        }
+    }
+    return string(runes)
+}
+
+// indent prefixes every non-empty line of s with prefix, so multi-line text
+// stays nested under its list item.
+func indent(prefix, s string) string {
+    lines := strings.Split(s, "\n")
+    for i, l := range lines {
+        if l != "" {
+            lines[i] = prefix + l
+        }
+    }
+    return strings.Join(lines, "\n")
+}
+
+// buildURL appends segments to base, escaping each path element but keeping
+// the slashes inside a segment (e.g. a tag named "api/v1.4.0").
+func buildURL(base string, segments ...string) string {
+    u := strings.TrimRight(base, "/")
+    for _, s := range segments {
+        parts := strings.Split(strings.Trim(s, "/"), "/")
+        for i, p := range parts {
+            parts[i] = url.PathEscape(p)
+        }
+        u += "/" + strings.Join(parts, "/")
+    }
+    return u
+}
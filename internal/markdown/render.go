@@ -2,78 +2,138 @@ package markdown
 
 import (
 	"bytes"
-	"fmt"
 	"text/template"
 
+	"github.com/felipevolpatto/scribe/internal/changelog"
 	cfg "github.com/felipevolpatto/scribe/internal/config"
-	"github.com/felipevolpatto/scribe/internal/parser"
 )
 
-// Render takes the final curated commits, the config, and the new version string
-// and returns the formatted changelog content as a string.
-func Render(version string, commits []*parser.ParsedCommit, config *cfg.Config) (string, error) {
-    type item struct {
-        Title string
-        Lines []string
-    }
-    byTitle := make([]item, 0, len(config.Sections))
-    for _, section := range config.Sections {
-        var lines []string
-        for _, pc := range commits {
-            // Route breaking changes to any section with empty types
-            if pc.IsBreaking && len(section.Types) == 0 {
-                hash := ""
-                if pc.Raw != nil {
-                    if len(pc.Raw.Hash) >= 7 {
-                        hash = pc.Raw.Hash[:7]
-                    } else {
-                        hash = pc.Raw.Hash
-                    }
-                }
-                suffix := ""
-                if hash != "" {
-                    suffix = fmt.Sprintf(" (%s)", hash)
-                }
-                lines = append(lines, "* "+pc.Description+suffix)
-                continue
-            }
-            for _, t := range section.Types {
-                if pc.Type == t {
-                    hash := ""
-                    if pc.Raw != nil {
-                        if len(pc.Raw.Hash) >= 7 {
-                            hash = pc.Raw.Hash[:7]
-                        } else {
-                            hash = pc.Raw.Hash
-                        }
-                    }
-                    suffix := ""
-                    if hash != "" {
-                        suffix = fmt.Sprintf(" (%s)", hash)
-                    }
-                    lines = append(lines, "* "+pc.Description+suffix)
-                    break
-                }
-            }
-        }
-        if len(lines) > 0 {
-            byTitle = append(byTitle, item{Title: section.Title, Lines: lines})
-        }
-    }
+// DefaultTemplate renders a release as a "## <version> - <date>" header (only
+// when the release is dated) followed by one "### <title>" block per section,
+// or a "No notable changes." line when there is none, with a bold heading per
+// scope group or a "**scope:**" prefix per item when the section's scope style
+// asks for it, and the Contributors and New Contributors blocks when they are
+// filled. Issues referenced outside the description, e.g. "Closes #45" in a
+// footer, follow the hash. Versions, hashes and issue references become links
+// when the forge or the issue tracker is known.
+const DefaultTemplate = `{{- if not .Date.IsZero }}## {{ if .CompareURL }}[{{ .Version }}]({{ .CompareURL }}){{ else }}{{ .Version }}{{ end }} - {{ date "2006-01-02" .Date }}
+
+{{ end }}
+{{- if not .Sections }}No notable changes.
+
+{{ end }}
+{{- range $section := .Sections }}### {{ $section.Title }}
+{{- range .Groups }}{{ if .Title }}
+
+**{{ .Title }}**{{ end }}
+{{- range .Items }}
+* {{ if and (eq $section.ScopeStyle "prefix") .ScopeTitle }}**{{ .ScopeTitle }}:** {{ end }}{{ linkReferences $.Forge .Description .References }}{{ if .Hash }} ({{ if .CommitURL }}[{{ shortHash .Hash }}]({{ .CommitURL }}){{ else }}{{ shortHash .Hash }}{{ end }}){{ end }}{{ with .TrailingReferences }} ({{ references . }}){{ end }}{{ if .Reverts }} (reverts {{ if .RevertsURL }}[{{ shortHash .Reverts }}]({{ .RevertsURL }}){{ else }}{{ shortHash .Reverts }}{{ end }}){{ end }}{{ if .By }} by {{ .By }}{{ end }}
+{{- if and $section.Breaking .BreakingNote }}
+{{ indent "  " .BreakingNote }}{{ end }}
+{{- end }}
+{{- end }}
+
+{{ end -}}
+{{- if .Contributors }}### Contributors
+{{- range .Contributors }}
+* {{ .Mention }}
+{{- end }}
 
-    const tmpl = `{{- range . }}### {{ .Title }}
-{{- range .Lines }}
-{{ . }}
+{{ end -}}
+{{- if .NewContributors }}### New Contributors
+{{- range .NewContributors }}
+* {{ .Mention }} made their first contribution{{ if .FirstCommit }} in {{ if .FirstCommitURL }}[{{ shortHash .FirstCommit }}]({{ .FirstCommitURL }}){{ else }}{{ shortHash .FirstCommit }}{{ end }}{{ end }}
 {{- end }}
 
 {{ end -}}`
 
-    t := template.Must(template.New("changelog").Parse(tmpl))
+// KeepAChangelogTemplate renders a release in the format of
+// https://keepachangelog.com: a "## [1.2.0] - 2024-05-01" header whose link is
+// a reference definition kept at the end of the file, and "- " items, grouped
+// or prefixed by scope and followed by the contributors like in
+// DefaultTemplate.
+const KeepAChangelogTemplate = `{{- if not .Date.IsZero }}## [{{ trimPrefix "v" .Version }}] - {{ date "2006-01-02" .Date }}
+
+{{ end }}
+{{- if not .Sections }}No notable changes.
+
+{{ end }}
+{{- range $section := .Sections }}### {{ .Title }}
+
+{{ range $i, $group := .Groups }}{{ if .Title }}{{ if $i }}
+{{ end }}**{{ .Title }}**
+
+{{ end }}{{ range .Items }}- {{ if .IsBreaking }}**BREAKING:** {{ end }}{{ if and (eq $section.ScopeStyle "prefix") .ScopeTitle }}**{{ .ScopeTitle }}:** {{ end }}{{ linkReferences $.Forge .Description .References }}{{ if .Hash }} ({{ if .CommitURL }}[{{ shortHash .Hash }}]({{ .CommitURL }}){{ else }}{{ shortHash .Hash }}{{ end }}){{ end }}{{ with .TrailingReferences }} ({{ references . }}){{ end }}{{ if .Reverts }} (reverts {{ if .RevertsURL }}[{{ shortHash .Reverts }}]({{ .RevertsURL }}){{ else }}{{ shortHash .Reverts }}{{ end }}){{ end }}{{ if .By }} by {{ .By }}{{ end }}
+{{ if .BreakingNote }}{{ indent "  " .BreakingNote }}
+{{ end }}{{ end }}{{ end }}
+{{ end -}}
+{{- if .Contributors }}### Contributors
+
+{{ range .Contributors }}- {{ .Mention }}
+{{ end }}
+{{ end -}}
+{{- if .NewContributors }}### New Contributors
+
+{{ range .NewContributors }}- {{ .Mention }} made their first contribution{{ if .FirstCommit }} in {{ if .FirstCommitURL }}[{{ shortHash .FirstCommit }}]({{ .FirstCommitURL }}){{ else }}{{ shortHash .FirstCommit }}{{ end }}{{ end }}
+{{ end }}
+{{ end -}}`
+
+// Render formats a built changelog with the template configured in
+// .scribe.yml, the preset's template or DefaultTemplate, and returns the
+// Markdown as a string.
+func Render(cl *changelog.Changelog, config *cfg.Config) (string, error) {
+    text := config.Template
+    if text == "" && config.Preset == cfg.PresetKeepAChangelog {
+        text = KeepAChangelogTemplate
+    }
+    if text == "" {
+        text = DefaultTemplate
+    }
+    t, err := template.New("changelog").Funcs(Funcs()).Parse(text)
+    if err != nil {
+        return "", err
+    }
     var buf bytes.Buffer
-    if err := t.Execute(&buf, byTitle); err != nil {
+    if err := t.Execute(&buf, cl); err != nil {
         return "", err
     }
     return buf.String(), nil
 }
 
+// Preamble returns the title a changelog written from scratch starts with,
+// followed by the paragraph the keepachangelog preset prescribes when it is
+// the configured preset.
+func Preamble(config *cfg.Config) string {
+    preamble := "# Changelog\n\n"
+    if config.Preset == cfg.PresetKeepAChangelog {
+        preamble += "All notable changes to this project will be documented in this file.\n\n" +
+            "The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),\n" +
+            "and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).\n\n"
+    }
+    return preamble
+}
+
+// TagMessage is the data available to the release.tag_message template.
+type TagMessage struct {
+    Tag     string
+    Version string
+    // Notes is the rendered release section.
+    Notes string
+}
 
+// RenderTagMessage executes the tag message template text, returning the
+// release notes unchanged when text is empty.
+func RenderTagMessage(text string, data TagMessage) (string, error) {
+    if text == "" {
+        return data.Notes, nil
+    }
+    t, err := template.New("tag_message").Funcs(Funcs()).Parse(text)
+    if err != nil {
+        return "", err
+    }
+    var buf bytes.Buffer
+    if err := t.Execute(&buf, data); err != nil {
+        return "", err
+    }
+    return buf.String(), nil
+}
@@ -3,8 +3,11 @@ package markdown
 import (
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/felipevolpatto/scribe/internal/changelog"
 	cfg "github.com/felipevolpatto/scribe/internal/config"
+	"github.com/felipevolpatto/scribe/internal/forge"
 	gitpkg "github.com/felipevolpatto/scribe/internal/git"
 	"github.com/felipevolpatto/scribe/internal/parser"
 )
@@ -16,7 +19,7 @@ func TestRender_BasicSections(t *testing.T) {
         {Type: "fix", Description: "correct bug", Raw: &gitpkg.RawCommit{Hash: "1234567"}},
         {Type: "feat", Description: "breaking api", IsBreaking: true, Raw: &gitpkg.RawCommit{Hash: "7654321"}},
     }
-    out, err := Render("v1.0.0", commits, config)
+    out, err := Render(changelog.Build("v1.0.0", "", time.Time{}, commits, config), config)
     if err != nil {
         t.Fatalf("render error: %v", err)
     }
@@ -36,7 +39,7 @@ func TestRender_OmitEmptyHashParentheses(t *testing.T) {
     commits := []*parser.ParsedCommit{
         {Type: "feat", Description: "no hash"},
     }
-    out, err := Render("v1.0.0", commits, config)
+    out, err := Render(changelog.Build("v1.0.0", "", time.Time{}, commits, config), config)
     if err != nil {
         t.Fatalf("render error: %v", err)
     }
@@ -55,7 +58,7 @@ func TestRender_BreakingSectionEmptyTypes(t *testing.T) {
         {Type: "feat", Description: "add a", Raw: &gitpkg.RawCommit{Hash: "abcdef1"}},
         {Type: "feat", Description: "breaking!", IsBreaking: true, Raw: &gitpkg.RawCommit{Hash: "1234567"}},
     }
-    out, err := Render("v1.0.0", commits, config)
+    out, err := Render(changelog.Build("v1.0.0", "", time.Time{}, commits, config), config)
     if err != nil {
         t.Fatalf("render error: %v", err)
     }
@@ -68,3 +71,204 @@ func TestRender_BreakingSectionEmptyTypes(t *testing.T) {
 }
 
 
+
+func TestRender_BreakingNote(t *testing.T) {
+    config := cfg.Default()
+    commits := []*parser.ParsedCommit{
+        {Type: "feat", Description: "new auth flow", IsBreaking: true, BreakingNote: "tokens issued before\nv2 are rejected", Raw: &gitpkg.RawCommit{Hash: "abcdef1"}},
+    }
+    out, err := Render(changelog.Build("v2.0.0", "", time.Time{}, commits, config), config)
+    if err != nil {
+        t.Fatalf("render error: %v", err)
+    }
+    if !strings.Contains(out, "* new auth flow (abcdef1)\n  tokens issued before\n  v2 are rejected") {
+        t.Fatalf("breaking note not rendered under its item: %q", out)
+    }
+}
+
+func TestRender_DefaultHeader(t *testing.T) {
+    config := cfg.Default()
+    commits := []*parser.ParsedCommit{
+        {Type: "fix", Description: "correct bug", Raw: &gitpkg.RawCommit{Hash: "1234567890"}},
+    }
+    date := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+    out, err := Render(changelog.Build("v1.0.1", "v1.0.0", date, commits, config), config)
+    if err != nil {
+        t.Fatalf("render error: %v", err)
+    }
+    want := "## v1.0.1 - 2024-05-01\n\n### Bug Fixes\n* correct bug (1234567)\n\n"
+    if out != want {
+        t.Fatalf("unexpected output:\n got %q\nwant %q", out, want)
+    }
+}
+
+func TestRender_CustomTemplate(t *testing.T) {
+    config := cfg.Default()
+    config.Template = `# {{ .Version }} ({{ date "Jan 2, 2006" .Date }}, since {{ .PreviousVersion }})
+{{ range .Sections }}{{ range .Items }}- [{{ upper .Type }}{{ with .Scope }}/{{ title . }}{{ end }}] {{ title .Description }} by {{ .Author }} {{ url "https://example.com/repo" "commit" .Hash }}
+{{ end }}{{ end }}`
+    commits := []*parser.ParsedCommit{
+        {Type: "feat", Scope: "api", Description: "add login", Raw: &gitpkg.RawCommit{Hash: "abcdef1234", AuthorName: "Jane Doe"}},
+    }
+    date := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+    out, err := Render(changelog.Build("v1.1.0", "v1.0.0", date, commits, config), config)
+    if err != nil {
+        t.Fatalf("render error: %v", err)
+    }
+    want := "# v1.1.0 (May 1, 2024, since v1.0.0)\n- [FEAT/Api] Add Login by Jane Doe https://example.com/repo/commit/abcdef1234\n"
+    if out != want {
+        t.Fatalf("unexpected output:\n got %q\nwant %q", out, want)
+    }
+}
+
+func TestRender_InvalidTemplate(t *testing.T) {
+    config := cfg.Default()
+    config.Template = "{{ .Version "
+    if _, err := Render(changelog.Build("v1.0.0", "", time.Time{}, nil, config), config); err == nil {
+        t.Fatal("expected parse error for invalid template")
+    }
+}
+
+func TestRender_ForgeLinks(t *testing.T) {
+    config := cfg.Default()
+    f, err := forge.New("git@github.com:owner/repo.git", cfg.Forge{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    commits := []*parser.ParsedCommit{
+        {Type: "fix", Description: "handle nil config (#12)", Raw: &gitpkg.RawCommit{Hash: "1234567890"}},
+    }
+    date := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+    cl := changelog.Build("v1.0.1", "v1.0.0", date, commits, config)
+    cl.Link(f, "v1.0.1")
+    out, err := Render(cl, config)
+    if err != nil {
+        t.Fatalf("render error: %v", err)
+    }
+    want := "## [v1.0.1](https://github.com/owner/repo/compare/v1.0.0...v1.0.1) - 2024-05-01\n\n" +
+        "### Bug Fixes\n" +
+        "* handle nil config ([#12](https://github.com/owner/repo/issues/12)) ([1234567](https://github.com/owner/repo/commit/1234567890))\n\n"
+    if out != want {
+        t.Fatalf("unexpected output:\n got %q\nwant %q", out, want)
+    }
+}
+
+func TestRender_KeepAChangelogPreset(t *testing.T) {
+    config := cfg.Default()
+    config.Preset = cfg.PresetKeepAChangelog
+    config.Sections = cfg.KeepAChangelogSections()
+    commits := []*parser.ParsedCommit{
+        {Type: "feat", Description: "add export", Raw: &gitpkg.RawCommit{Hash: "1111111aaa"}},
+        {Type: "feat", Description: "drop v1 API", IsBreaking: true, BreakingNote: "use /v2", Raw: &gitpkg.RawCommit{Hash: "2222222bbb"}},
+        {Type: "fix", Description: "correct bug", Raw: &gitpkg.RawCommit{Hash: "3333333ccc"}},
+        {Type: "revert", Description: "feat: add import", Reverts: "4444444ddd", Raw: &gitpkg.RawCommit{Hash: "5555555eee"}},
+    }
+    date := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+    out, err := Render(changelog.Build("v1.2.0", "v1.1.0", date, commits, config), config)
+    if err != nil {
+        t.Fatalf("render error: %v", err)
+    }
+    want := "## [1.2.0] - 2024-05-01\n\n" +
+        "### Added\n\n- add export (1111111)\n- **BREAKING:** drop v1 API (2222222)\n  use /v2\n\n" +
+        "### Removed\n\n- feat: add import (5555555) (reverts 4444444)\n\n" +
+        "### Fixed\n\n- correct bug (3333333)\n\n"
+    if out != want {
+        t.Fatalf("unexpected output:\n got %q\nwant %q", out, want)
+    }
+}
+
+func TestRender_ScopeStyles(t *testing.T) {
+    commits := []*parser.ParsedCommit{
+        {Type: "feat", Scope: "cli", Description: "add flag"},
+        {Type: "feat", Scope: "api", Description: "add endpoint"},
+        {Type: "feat", Description: "add docs"},
+    }
+    config := cfg.Default()
+    config.Order = cfg.OrderScope
+    config.ScopeStyle = cfg.ScopeStyleHeading
+    config.ScopeNames = map[string]string{"api": "Public API"}
+    out, err := Render(changelog.Build("v1.0.0", "", time.Time{}, commits, config), config)
+    if err != nil {
+        t.Fatalf("render error: %v", err)
+    }
+    want := "### New Features\n* add docs\n\n**cli**\n* add flag\n\n**Public API**\n* add endpoint\n\n"
+    if out != want {
+        t.Fatalf("unexpected scope headings:\n%q\nwant\n%q", out, want)
+    }
+
+    config.ScopeStyle = cfg.ScopeStylePrefix
+    out, err = Render(changelog.Build("v1.0.0", "", time.Time{}, commits, config), config)
+    if err != nil {
+        t.Fatalf("render error: %v", err)
+    }
+    want = "### New Features\n* add docs\n* **cli:** add flag\n* **Public API:** add endpoint\n\n"
+    if out != want {
+        t.Fatalf("unexpected scope prefixes:\n%q\nwant\n%q", out, want)
+    }
+
+    config.Preset = cfg.PresetKeepAChangelog
+    config.ScopeStyle = cfg.ScopeStyleHeading
+    out, err = Render(changelog.Build("v1.0.0", "", time.Time{}, commits, config), config)
+    if err != nil {
+        t.Fatalf("render error: %v", err)
+    }
+    want = "### New Features\n\n- add docs\n\n**cli**\n\n- add flag\n\n**Public API**\n\n- add endpoint\n\n"
+    if out != want {
+        t.Fatalf("unexpected keepachangelog scope headings:\n%q\nwant\n%q", out, want)
+    }
+}
+
+func TestRender_Contributors(t *testing.T) {
+    config := cfg.Default()
+    config.ScopeStyle = cfg.ScopeStyleHeading
+    config.Contributors = cfg.Contributors{Handles: map[string]string{"jane@example.com": "janedoe"}, ByLine: true, List: true}
+    f, err := forge.New("git@github.com:owner/repo.git", cfg.Forge{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    commits := []*parser.ParsedCommit{
+        {Type: "feat", Scope: "api", Description: "add login", Raw: &gitpkg.RawCommit{Hash: "1234567890", AuthorName: "Jane", AuthorEmail: "jane@example.com"}},
+    }
+    cl := changelog.Build("v1.1.0", "", time.Time{}, commits, config)
+    cl.FindNewContributors(nil)
+    cl.Link(f, "v1.1.0")
+    out, err := Render(cl, config)
+    if err != nil {
+        t.Fatalf("render error: %v", err)
+    }
+    want := "### New Features\n\n**api**\n" +
+        "* add login ([1234567](https://github.com/owner/repo/commit/1234567890)) by @janedoe\n\n" +
+        "### Contributors\n* @janedoe\n\n" +
+        "### New Contributors\n* @janedoe made their first contribution in [1234567](https://github.com/owner/repo/commit/1234567890)\n\n"
+    if out != want {
+        t.Fatalf("unexpected output:\n got %q\nwant %q", out, want)
+    }
+}
+
+func TestRender_References(t *testing.T) {
+    config := cfg.Default()
+    config.Issues = []cfg.IssueTracker{{Type: "jira", Keys: []string{"PROJ"}, URL: "https://example.atlassian.net/browse/{id}"}}
+    f, err := forge.New("git@github.com:owner/repo.git", cfg.Forge{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    pc, err := parser.ParseWith("fix: handle PROJ-7 and UTF-8 names (#12)\n\nCloses #45\nFixes: org/repo#9\nRefs: PROJ-123", config, config.IssueKeys()...)
+    if err != nil {
+        t.Fatal(err)
+    }
+    pc.Raw = &gitpkg.RawCommit{Hash: "1234567890"}
+    cl := changelog.Build("v1.0.1", "", time.Time{}, []*parser.ParsedCommit{pc}, config)
+    cl.Link(f, "v1.0.1")
+    out, err := Render(cl, config)
+    if err != nil {
+        t.Fatalf("render error: %v", err)
+    }
+    want := "### Bug Fixes\n" +
+        "* handle [PROJ-7](https://example.atlassian.net/browse/PROJ-7) and UTF-8 names ([#12](https://github.com/owner/repo/issues/12))" +
+        " ([1234567](https://github.com/owner/repo/commit/1234567890))" +
+        " (closes [#45](https://github.com/owner/repo/issues/45), fixes [org/repo#9](https://github.com/org/repo/issues/9)," +
+        " refs [PROJ-123](https://example.atlassian.net/browse/PROJ-123))\n\n"
+    if out != want {
+        t.Fatalf("unexpected output:\n got %q\nwant %q", out, want)
+    }
+}
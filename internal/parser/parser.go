@@ -2,34 +2,89 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
+	"strings"
 
 	gitpkg "github.com/felipevolpatto/scribe/internal/git"
 )
 
 // ParsedCommit is the structured representation of a Conventional Commit.
 type ParsedCommit struct {
-    Type        string
-    Scope       string
-    Description string
-    IsBreaking  bool
-    Raw         *gitpkg.RawCommit
+    Type         string
+    Scope        string
+    Description  string
+    Body         string
+    Footers      []Footer
+    IsBreaking   bool
+    BreakingNote string
+    // PullRequest is the pull or merge request the commit landed with, e.g.
+    // "#123" from a "(#123)" squash-merge suffix; empty when unknown.
+    PullRequest string
+    // Reverts is the hash, possibly abbreviated, of the commit a revert
+    // commit undoes, from its "This reverts commit <hash>." line.
+    Reverts string
+    // References lists the issues referenced by the subject, the body and
+    // the footers.
+    References []IssueRef
+    Raw        *gitpkg.RawCommit
+}
+
+// Footer is a single git trailer found at the end of a commit message,
+// e.g. "Reviewed-by: Jane" or "BREAKING CHANGE: drop Go 1.20".
+type Footer struct {
+    Token string
+    Value string
 }
 
 var (
     // Matches: type(scope)!: description
     // Groups: 1=type 2=scope (optional) 3=! (optional) 4=description
     conventionalRe = regexp.MustCompile(`^(\w+)(?:\(([\w\/-]+)\))?(!)?:\s+(.+)$`)
+
+    // Matches the pull request suffix forges add to squash-merged commits.
+    // Groups: 1=reference such as "#123" or "!45"
+    pullRequestSuffixRe = regexp.MustCompile(`\s\(([#!]\d+)\)$`)
+
+    // Matches the header of a merge commit created for a pull request.
+    // Groups: 1=GitHub number 2=Bitbucket number
+    mergeHeaderRe = regexp.MustCompile(`^(?:Merge pull request #(\d+) from \S+|Merged in \S+ \(pull request #(\d+)\))`)
+    // Matches the header of a GitLab merge commit and the line of its body
+    // naming the merge request.
+    // Groups: 1=number
+    gitlabMergeRe   = regexp.MustCompile(`^Merge branch '[^']+' into '[^']+'`)
+    gitlabRequestRe = regexp.MustCompile(`^See merge request \S*!(\d+)$`)
+
+    // Matches the header git writes for 'git revert'.
+    // Groups: 1=header of the reverted commit
+    gitRevertRe = regexp.MustCompile(`^Revert "(.+)"$`)
+    // Matches the line of a revert commit's body naming the reverted commit.
+    // Groups: 1=hash
+    revertsRe = regexp.MustCompile(`(?m)^This reverts commit ([0-9a-fA-F]{7,40})\b`)
+
+    // Matches a footer line: "Token: value" or "Token #value".
+    // Groups: 1=token 2=separator 3=value
+    footerRe = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z][\w-]*)(: | #)(.*)$`)
 )
 
 // Parse takes a raw commit message and returns a structured ParsedCommit.
-// Returns an error if the message does not conform to the spec.
+// The first line is matched against the Conventional Commits header; any
+// following lines are split into a free-form body and a trailing block of
+// footers. Returns an error if the header does not conform to the spec.
+// The 'Revert "<header>"' messages of git revert are accepted too, as
+// commits of type "revert" whose description is the reverted header.
 func Parse(message string) (*ParsedCommit, error) {
-    if message == "" {
+    message = strings.ReplaceAll(message, "\r\n", "\n")
+    if strings.TrimSpace(message) == "" {
         return nil, errors.New("empty commit message")
     }
 
-    matches := conventionalRe.FindStringSubmatch(message)
+    lines := strings.Split(strings.TrimLeft(message, "\n"), "\n")
+    header := strings.TrimSpace(lines[0])
+    if m := gitRevertRe.FindStringSubmatch(header); m != nil {
+        header = "revert: " + m[1]
+    }
+    matches := conventionalRe.FindStringSubmatch(header)
     if matches == nil {
         return nil, errors.New("commit message does not follow Conventional Commits")
     }
@@ -40,7 +95,158 @@ func Parse(message string) (*ParsedCommit, error) {
         Description: matches[4],
         IsBreaking:  matches[3] == "!",
     }
+    if m := pullRequestSuffixRe.FindStringSubmatch(parsed.Description); m != nil {
+        parsed.PullRequest = m[1]
+    }
+    parsed.Body, parsed.Footers = splitBodyAndFooters(lines[1:])
+    if strings.EqualFold(parsed.Type, "revert") {
+        if m := revertsRe.FindStringSubmatch(message); m != nil {
+            parsed.Reverts = strings.ToLower(m[1])
+        }
+    }
+    for _, f := range parsed.Footers {
+        if IsBreakingToken(f.Token) {
+            parsed.IsBreaking = true
+            if parsed.BreakingNote == "" {
+                parsed.BreakingNote = f.Value
+            }
+        }
+    }
+    parsed.References = findReferences(parsed, nil)
     return parsed, nil
 }
 
+// UnwrapMerge rewrites the message of a pull request merge commit, such as
+// "Merge pull request #123 from org/branch" followed by the pull request
+// title, into the message of a squash merge: the title with a " (#123)"
+// suffix, then the rest of the body. GitHub, GitLab and Bitbucket merge
+// messages are recognized; ok is false for any other message, or when the
+// body holds no title.
+func UnwrapMerge(message string) (unwrapped string, ok bool) {
+    message = strings.ReplaceAll(message, "\r\n", "\n")
+    lines := strings.Split(strings.TrimSpace(message), "\n")
+    header := strings.TrimSpace(lines[0])
+    ref := ""
+    if m := mergeHeaderRe.FindStringSubmatch(header); m != nil {
+        ref = "#" + m[1] + m[2]
+    }
+    var body []string
+    for _, line := range lines[1:] {
+        if m := gitlabRequestRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil && gitlabMergeRe.MatchString(header) {
+            ref = "!" + m[1]
+            continue
+        }
+        body = append(body, line)
+    }
+    if ref == "" {
+        return "", false
+    }
+    for len(body) > 0 && strings.TrimSpace(body[0]) == "" {
+        body = body[1:]
+    }
+    if len(body) == 0 {
+        return "", false
+    }
+    title := strings.TrimSpace(body[0])
+    if !strings.HasSuffix(title, "("+ref+")") {
+        title += " (" + ref + ")"
+    }
+    rest := strings.TrimSpace(strings.Join(body[1:], "\n"))
+    if rest == "" {
+        return title, true
+    }
+    return title + "\n\n" + rest, true
+}
 
+// TypeResolver maps the type written in a commit header to the canonical type
+// name; *config.Config implements it with the configured types and aliases.
+type TypeResolver interface {
+    CanonicalType(typ string) (string, bool)
+}
+
+// ParseWith parses message like Parse and then normalizes its type with
+// types, so that an alias such as "feature" becomes "feat". A type types does
+// not know is an error, except for revert commits, which are always kept so
+// that CancelReverts can pair them with the commits they undo. Issue keys of
+// the projects in keys, such as "PROJ" for "PROJ-123", are found in the
+// subject and body as well as in the reference footers.
+func ParseWith(message string, types TypeResolver, keys ...string) (*ParsedCommit, error) {
+    parsed, err := Parse(message)
+    if err != nil {
+        return nil, err
+    }
+    if len(keys) > 0 {
+        parsed.References = findReferences(parsed, keys)
+    }
+    typ, ok := types.CanonicalType(parsed.Type)
+    if !ok && parsed.Reverts == "" {
+        return nil, fmt.Errorf("type %q is not allowed", parsed.Type)
+    }
+    if ok {
+        parsed.Type = typ
+    }
+    return parsed, nil
+}
+
+// IsBreakingToken reports whether a footer token declares a breaking change.
+func IsBreakingToken(token string) bool {
+    return token == "BREAKING CHANGE" || token == "BREAKING-CHANGE"
+}
+
+// splitBodyAndFooters separates the lines after the header into the body and
+// the footers. The footers are the last paragraph, when each of its lines is
+// either a trailer or an indented continuation of the previous trailer's
+// value; otherwise every line belongs to the body, so that prose such as
+// "Note: see below." in the middle of the body stays there.
+func splitBodyAndFooters(lines []string) (string, []Footer) {
+    end := len(lines)
+    for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+        end--
+    }
+    start := end
+    for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+        start--
+    }
+    if start == end || !footerRe.MatchString(lines[start]) {
+        return strings.TrimSpace(strings.Join(lines, "\n")), nil
+    }
+    for _, line := range lines[start:end] {
+        if !footerRe.MatchString(line) && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+            return strings.TrimSpace(strings.Join(lines, "\n")), nil
+        }
+    }
+
+    body := strings.TrimSpace(strings.Join(lines[:start], "\n"))
+    var footers []Footer
+    for _, line := range lines[start:end] {
+        if m := footerRe.FindStringSubmatch(line); m != nil {
+            value := m[3]
+            if m[2] == " #" {
+                value = "#" + value
+            }
+            footers = append(footers, Footer{Token: m[1], Value: value})
+            continue
+        }
+        last := &footers[len(footers)-1]
+        last.Value += "\n" + line
+    }
+    for i := range footers {
+        footers[i].Value = strings.TrimSpace(footers[i].Value)
+    }
+    return body, footers
+}
+
+// Skipped is a commit left out of the changelog, with the reason why.
+type Skipped struct {
+    Raw    *gitpkg.RawCommit
+    Reason string
+    // Parsed is set when the commit is a valid Conventional Commit that was
+    // excluded by the configuration, e.g. by ignore_scopes.
+    Parsed *ParsedCommit
+}
+
+// Header returns the first line of the skipped commit's message.
+func (s Skipped) Header() string {
+    header, _, _ := strings.Cut(strings.TrimSpace(s.Raw.Message), "\n")
+    return strings.TrimSpace(header)
+}
@@ -34,3 +34,151 @@ func TestParse_InvalidMessage(t *testing.T) {
 }
 
 
+
+func TestParse_BodyAndFooters(t *testing.T) {
+    msg := "feat(api): add token endpoint\n\nIssue tokens for service accounts.\nSecond line.\n\nReviewed-by: Jane Doe\nRefs #42\nBREAKING CHANGE: the /auth endpoint\n  now requires a client id\n"
+    parsed, err := Parse(msg)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if parsed.Description != "add token endpoint" {
+        t.Fatalf("unexpected description: %q", parsed.Description)
+    }
+    if parsed.Body != "Issue tokens for service accounts.\nSecond line." {
+        t.Fatalf("unexpected body: %q", parsed.Body)
+    }
+    if len(parsed.Footers) != 3 {
+        t.Fatalf("expected 3 footers, got %+v", parsed.Footers)
+    }
+    if parsed.Footers[0] != (Footer{Token: "Reviewed-by", Value: "Jane Doe"}) {
+        t.Fatalf("unexpected first footer: %+v", parsed.Footers[0])
+    }
+    if parsed.Footers[1] != (Footer{Token: "Refs", Value: "#42"}) {
+        t.Fatalf("unexpected second footer: %+v", parsed.Footers[1])
+    }
+    if !parsed.IsBreaking {
+        t.Fatal("expected BREAKING CHANGE footer to mark the commit as breaking")
+    }
+    if parsed.BreakingNote != "the /auth endpoint\n  now requires a client id" {
+        t.Fatalf("unexpected breaking note: %q", parsed.BreakingNote)
+    }
+}
+
+func TestParse_BreakingChangeHyphenToken(t *testing.T) {
+    parsed, err := Parse("fix: drop legacy flag\n\nBREAKING-CHANGE: --legacy was removed")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if !parsed.IsBreaking || parsed.BreakingNote != "--legacy was removed" {
+        t.Fatalf("expected breaking note from hyphenated token, got %+v", parsed)
+    }
+    if parsed.Body != "" {
+        t.Fatalf("expected empty body, got %q", parsed.Body)
+    }
+}
+
+func TestParse_BodyWithoutFooters(t *testing.T) {
+    parsed, err := Parse("docs: explain config\n\nThe sections key is ordered.\n")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if parsed.Body != "The sections key is ordered." || len(parsed.Footers) != 0 || parsed.IsBreaking {
+        t.Fatalf("unexpected parse result: %+v", parsed)
+    }
+}
+
+type aliases map[string]string
+
+func (a aliases) CanonicalType(typ string) (string, bool) {
+    t, ok := a[typ]
+    return t, ok
+}
+
+func TestParseWith_NormalizesAliases(t *testing.T) {
+    types := aliases{"feat": "feat", "feature": "feat"}
+    parsed, err := ParseWith("feature(ui): add button", types)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if parsed.Type != "feat" || parsed.Scope != "ui" {
+        t.Fatalf("parsed mismatch: got %+v", parsed)
+    }
+    if _, err := ParseWith("wip: half done", types); err == nil {
+        t.Fatal("expected an error for an unknown type")
+    }
+}
+
+func TestParse_PullRequestSuffix(t *testing.T) {
+    parsed, err := Parse("feat: add login (#123)")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if parsed.PullRequest != "#123" || parsed.Description != "add login (#123)" {
+        t.Fatalf("unexpected pull request: %+v", parsed)
+    }
+}
+
+func TestUnwrapMerge(t *testing.T) {
+    tests := []struct {
+        message string
+        want    string
+        ok      bool
+    }{
+        {"Merge pull request #123 from org/login\n\nfeat: add login\n\nDetails.", "feat: add login (#123)\n\nDetails.", true},
+        {"Merge pull request #7 from org/x\n\nfix: y (#7)", "fix: y (#7)", true},
+        {"Merge branch 'login' into 'main'\n\nfeat: add login\n\nSee merge request group/app!45", "feat: add login (!45)", true},
+        {"Merged in feature/x (pull request #12)\n\nfix: crash\n\nApproved-by: Jane", "fix: crash (#12)\n\nApproved-by: Jane", true},
+        {"Merge pull request #9 from org/empty", "", false},
+        {"Merge branch 'main' into topic", "", false},
+        {"feat: add login", "", false},
+    }
+    for _, tt := range tests {
+        got, ok := UnwrapMerge(tt.message)
+        if got != tt.want || ok != tt.ok {
+            t.Errorf("UnwrapMerge(%q) = %q, %v; want %q, %v", tt.message, got, ok, tt.want, tt.ok)
+        }
+    }
+}
+
+func TestParse_Reverts(t *testing.T) {
+    tests := []struct {
+        msg     string
+        desc    string
+        reverts string
+    }{
+        {"Revert \"feat: add login\"\n\nThis reverts commit 0123456789ABCDEF0123456789abcdef01234567.", "feat: add login", "0123456789abcdef0123456789abcdef01234567"},
+        {"revert: feat: add login\n\nThis reverts commit abcdef1.", "feat: add login", "abcdef1"},
+        {"revert: drop the old API", "drop the old API", ""},
+    }
+    for _, tt := range tests {
+        parsed, err := Parse(tt.msg)
+        if err != nil {
+            t.Fatalf("unexpected error for %q: %v", tt.msg, err)
+        }
+        if parsed.Type != "revert" || parsed.Description != tt.desc || parsed.Reverts != tt.reverts {
+            t.Fatalf("parsed mismatch for %q: got %+v", tt.msg, parsed)
+        }
+    }
+}
+
+func TestParse_TokenParagraphInBody(t *testing.T) {
+    msg := "fix: speed up the scan\n\nNote: see below.\n\nFixes #12 by reworking the loop.\nThe old loop was quadratic.\n\nReviewed-by: Jane Doe\n"
+    parsed, err := Parse(msg)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if parsed.Body != "Note: see below.\n\nFixes #12 by reworking the loop.\nThe old loop was quadratic." {
+        t.Fatalf("unexpected body: %q", parsed.Body)
+    }
+    if len(parsed.Footers) != 1 || parsed.Footers[0] != (Footer{Token: "Reviewed-by", Value: "Jane Doe"}) {
+        t.Fatalf("unexpected footers: %+v", parsed.Footers)
+    }
+
+    parsed, err = Parse("fix: speed up the scan\n\nNote: see below.\nMore prose follows.\n")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if parsed.Body != "Note: see below.\nMore prose follows." || len(parsed.Footers) != 0 {
+        t.Fatalf("prose paragraph parsed as footers: %+v", parsed)
+    }
+}
@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// IssueRef is a reference to an issue found in a commit message, such as
+// "Closes #45", "Fixes: org/repo#9" or "Refs: PROJ-123".
+type IssueRef struct {
+    // Action is the lowercased keyword or footer token introducing the
+    // reference, e.g. "closes", "fixes" or "refs"; empty for a plain mention.
+    Action string
+    // Repo is the "org/repo" of an issue of another repository; empty for the
+    // commit's own repository and for issue keys.
+    Repo string
+    // ID is the issue number with its "#", e.g. "#45", or the key of a
+    // Jira-style issue, e.g. "PROJ-123".
+    ID string
+    // InSubject is set when the reference is written in the subject line.
+    InSubject bool
+}
+
+// String returns the reference as written, e.g. "org/repo#9".
+func (r IssueRef) String() string {
+    return r.Repo + r.ID
+}
+
+// IsKey reports whether the reference is a Jira-style key rather than a
+// "#123" issue number.
+func (r IssueRef) IsKey() bool {
+    return !strings.HasPrefix(r.ID, "#")
+}
+
+var (
+    // Matches an issue number, optionally of another repository.
+    // Groups: 1=repository (optional) 2=number including the "#"
+    issueNumberRe = regexp.MustCompile(`(?:^|[\s(,\[])(?:([\w.-]+/[\w.-]+))?(#\d+)\b`)
+    // Matches a Jira-style issue key.
+    // Groups: 1=key
+    issueKeyRe = regexp.MustCompile(`\b([A-Z][A-Z0-9_]+-\d+)\b`)
+    // Matches a closing keyword right before a reference in free text.
+    // Groups: 1=keyword
+    closingKeywordRe = regexp.MustCompile(`(?i)\b(close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s*$`)
+)
+
+// referenceTokens are the footer tokens whose value is a list of issues; the
+// values of these footers may use any issue key.
+var referenceTokens = map[string]bool{
+    "close": true, "closes": true, "closed": true,
+    "fix": true, "fixes": true, "fixed": true,
+    "resolve": true, "resolves": true, "resolved": true,
+    "ref": true, "refs": true, "references": true,
+    "related": true, "related-to": true, "relates-to": true,
+    "see": true, "see-also": true,
+    "issue": true, "issues": true,
+}
+
+// findReferences collects the issue references of the subject, the body and
+// the footers of parsed, in that order and without duplicates. Issue numbers
+// are found everywhere; issue keys only in the value of reference footers
+// such as "Refs:", and in the subject and body when their project is one of
+// keys, since words like "UTF-8" look like keys too.
+func findReferences(parsed *ParsedCommit, keys []string) []IssueRef {
+    var keyRe *regexp.Regexp
+    if len(keys) > 0 {
+        quoted := make([]string, len(keys))
+        for i, k := range keys {
+            quoted[i] = regexp.QuoteMeta(k)
+        }
+        keyRe = regexp.MustCompile(`\b((?:` + strings.Join(quoted, "|") + `)-\d+)\b`)
+    }
+
+    var refs []IssueRef
+    index := map[string]int{}
+    add := func(ref IssueRef) {
+        if i, ok := index[ref.String()]; ok {
+            if refs[i].Action == "" {
+                refs[i].Action = ref.Action
+            }
+            return
+        }
+        index[ref.String()] = len(refs)
+        refs = append(refs, ref)
+    }
+    scan := func(text, action string, anyKey, inSubject bool) {
+        re := keyRe
+        if anyKey {
+            re = issueKeyRe
+        }
+        for _, m := range scanReferences(text, re) {
+            ref := m.ref
+            ref.Action, ref.InSubject = action, inSubject
+            if ref.Action == "" {
+                ref.Action = closingKeyword(text[:m.start])
+            }
+            add(ref)
+        }
+    }
+
+    scan(parsed.Description, "", false, true)
+    scan(parsed.Body, "", false, false)
+    for _, f := range parsed.Footers {
+        token := strings.ToLower(f.Token)
+        if referenceTokens[token] {
+            scan(f.Value, token, true, false)
+        } else {
+            scan(f.Value, "", false, false)
+        }
+    }
+    return refs
+}
+
+// mention is a reference found in a text, at text[start:end].
+type mention struct {
+    ref        IssueRef
+    start, end int
+}
+
+// scanReferences returns the issue numbers mentioned in text, and the issue
+// keys matched by keyRe unless it is nil, in the order they appear.
+func scanReferences(text string, keyRe *regexp.Regexp) []mention {
+    var found []mention
+    for _, m := range issueNumberRe.FindAllStringSubmatchIndex(text, -1) {
+        f := mention{ref: IssueRef{ID: text[m[4]:m[5]]}, start: m[4], end: m[5]}
+        if m[2] >= 0 {
+            f.ref.Repo, f.start = text[m[2]:m[3]], m[2]
+        }
+        found = append(found, f)
+    }
+    if keyRe == nil {
+        return found
+    }
+    numbers := len(found)
+    for _, m := range keyRe.FindAllStringSubmatchIndex(text, -1) {
+        found = append(found, mention{ref: IssueRef{ID: text[m[2]:m[3]]}, start: m[2], end: m[3]})
+    }
+    if numbers > 0 && len(found) > numbers {
+        sort.SliceStable(found, func(i, j int) bool { return found[i].start < found[j].start })
+    }
+    return found
+}
+
+// ReplaceReferences returns text with each issue reference it mentions, e.g.
+// "#45", "org/repo#9" or "PROJ-123", replaced by what replace returns for it;
+// an empty result keeps the reference as written. References that are
+// already the text of a Markdown link, or part of a URL path, are left
+// alone.
+func ReplaceReferences(text string, replace func(IssueRef) string) string {
+    var b strings.Builder
+    last := 0
+    for _, m := range scanReferences(text, issueKeyRe) {
+        if m.start < last || strings.HasPrefix(text[m.end:], "](") || (m.start > 0 && text[m.start-1] == '/') {
+            continue
+        }
+        if r := replace(m.ref); r != "" {
+            b.WriteString(text[last:m.start])
+            b.WriteString(r)
+            last = m.end
+        }
+    }
+    b.WriteString(text[last:])
+    return b.String()
+}
+
+// closingKeyword returns the lowercased closing keyword, such as "closes",
+// that text ends with, or "".
+func closingKeyword(text string) string {
+    if m := closingKeywordRe.FindStringSubmatch(text); m != nil {
+        return strings.ToLower(m[1])
+    }
+    return ""
+}
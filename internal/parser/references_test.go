@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse_References(t *testing.T) {
+    message := "fix(api): handle UTF-8 names (#12)\n\n" +
+        "The PROJ-7 workaround is gone; closes #45 and see #46.\n\n" +
+        "Fixes: org/repo#9\n" +
+        "Refs: PROJ-123, #45\n" +
+        "Reviewed-by: Jane"
+    parsed, err := Parse(message)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    want := []IssueRef{
+        {ID: "#12", InSubject: true},
+        {Action: "closes", ID: "#45"},
+        {ID: "#46"},
+        {Action: "fixes", Repo: "org/repo", ID: "#9"},
+        {Action: "refs", ID: "PROJ-123"},
+    }
+    if !reflect.DeepEqual(parsed.References, want) {
+        t.Fatalf("unexpected references:\n got %+v\nwant %+v", parsed.References, want)
+    }
+}
+
+func TestParseWith_IssueKeys(t *testing.T) {
+    types := aliases{"feat": "feat"}
+    parsed, err := ParseWith("feat: PROJ-7 export to UTF-8 CSV\n\nAlso OPS-2.", types, "PROJ")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    want := []IssueRef{{ID: "PROJ-7", InSubject: true}}
+    if !reflect.DeepEqual(parsed.References, want) {
+        t.Fatalf("unexpected references:\n got %+v\nwant %+v", parsed.References, want)
+    }
+    if !parsed.References[0].IsKey() || parsed.References[0].String() != "PROJ-7" {
+        t.Fatalf("unexpected key reference: %+v", parsed.References[0])
+    }
+}
+
+func TestReplaceReferences(t *testing.T) {
+    text := "fix PROJ-7 and org/repo#9 (#12), see [#3](https://x/3) and https://x/browse/PROJ-8"
+    var seen []string
+    got := ReplaceReferences(text, func(r IssueRef) string {
+        seen = append(seen, r.String())
+        if r.IsKey() {
+            return ""
+        }
+        return "<" + r.String() + ">"
+    })
+    want := "fix PROJ-7 and <org/repo#9> (<#12>), see [#3](https://x/3) and https://x/browse/PROJ-8"
+    if got != want {
+        t.Fatalf("unexpected result:\n got %q\nwant %q", got, want)
+    }
+    if !reflect.DeepEqual(seen, []string{"PROJ-7", "org/repo#9", "#12"}) {
+        t.Fatalf("unexpected references offered: %v", seen)
+    }
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	gitpkg "github.com/felipevolpatto/scribe/internal/git"
+)
+
+// CancelReverts removes the revert commits whose reverted commit is part of
+// the same release, together with that commit, since the release contains
+// neither change. commits and skipped are the commits of the release, newest
+// first; a revert of a skipped commit is removed too. Reverting a revert
+// brings the originally reverted commit back. Reverts of commits from earlier
+// releases are kept. The removed commits are returned as skipped, with the
+// reason they were removed.
+func CancelReverts(commits []*ParsedCommit, skipped []Skipped) ([]*ParsedCommit, []Skipped) {
+    active := make([]bool, len(commits))
+    for i := range active {
+        active[i] = true
+    }
+    // cancelled maps the index of a revert that removed a commit of the
+    // release to the index of that commit.
+    cancelled := map[int]int{}
+    var dropped []Skipped
+
+    // Walk oldest first so that a revert sees the commits made before it.
+    for i := len(commits) - 1; i >= 0; i-- {
+        r := commits[i]
+        if r.Reverts == "" {
+            continue
+        }
+        target := -1
+        for j := len(commits) - 1; j > i; j-- {
+            if commits[j].Raw != nil && sameCommit(commits[j].Raw.Hash, r.Reverts) {
+                target = j
+                break
+            }
+        }
+        switch {
+        case target >= 0 && active[target]:
+            active[i], active[target] = false, false
+            cancelled[i] = target
+        case target >= 0:
+            // target is a revert that removed a commit: reverting it
+            // brings that commit back.
+            if original, ok := cancelled[target]; ok {
+                active[i], active[original] = false, true
+            } else {
+                active[i] = false
+            }
+        default:
+            for _, s := range skipped {
+                if s.Raw != nil && sameCommit(s.Raw.Hash, r.Reverts) {
+                    active[i] = false
+                    break
+                }
+            }
+        }
+    }
+
+    var kept []*ParsedCommit
+    for i, pc := range commits {
+        if active[i] {
+            kept = append(kept, pc)
+            continue
+        }
+        reason := "reverted in this release"
+        if pc.Reverts != "" {
+            reason = fmt.Sprintf("reverts %s from this release", gitpkg.ShortHash(pc.Reverts))
+        }
+        dropped = append(dropped, Skipped{Raw: pc.Raw, Reason: reason, Parsed: pc})
+    }
+    return kept, append(skipped, dropped...)
+}
+
+// sameCommit reports whether hash is the full hash of the commit abbreviated
+// as ref.
+func sameCommit(hash, ref string) bool {
+    return len(ref) >= 7 && strings.HasPrefix(strings.ToLower(hash), strings.ToLower(ref))
+}
+
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	gitpkg "github.com/felipevolpatto/scribe/internal/git"
+)
+
+func TestCancelReverts(t *testing.T) {
+    commit := func(hash, desc, reverts string) *ParsedCommit {
+        return &ParsedCommit{Type: "feat", Description: desc, Reverts: reverts, Raw: &gitpkg.RawCommit{Hash: hash}}
+    }
+    descriptions := func(commits []*ParsedCommit) string {
+        var s []string
+        for _, c := range commits {
+            s = append(s, c.Description)
+        }
+        return strings.Join(s, ",")
+    }
+    tests := []struct {
+        name    string
+        commits []*ParsedCommit
+        skipped []Skipped
+        want    string
+        dropped int
+    }{
+        {
+            name: "pair in range",
+            commits: []*ParsedCommit{
+                commit("3333333aaaa", "undo login", "1111111"),
+                commit("2222222aaaa", "add search", ""),
+                commit("1111111aaaa", "add login", ""),
+            },
+            want:    "add search",
+            dropped: 2,
+        },
+        {
+            name:    "earlier release",
+            commits: []*ParsedCommit{commit("3333333aaaa", "undo old", "9999999"), commit("2222222aaaa", "add search", "")},
+            want:    "undo old,add search",
+        },
+        {
+            name: "revert of a revert",
+            commits: []*ParsedCommit{
+                commit("3333333aaaa", "redo login", "2222222aaaa"),
+                commit("2222222aaaa", "undo login", "1111111"),
+                commit("1111111aaaa", "add login", ""),
+            },
+            want:    "add login",
+            dropped: 2,
+        },
+        {
+            name:    "skipped commit",
+            commits: []*ParsedCommit{commit("3333333aaaa", "undo wip", "1111111")},
+            skipped: []Skipped{{Raw: &gitpkg.RawCommit{Hash: "1111111aaaa"}, Reason: "not conventional"}},
+            want:    "",
+            dropped: 1,
+        },
+    }
+    for _, tt := range tests {
+        kept, skipped := CancelReverts(tt.commits, tt.skipped)
+        if descriptions(kept) != tt.want || len(skipped)-len(tt.skipped) != tt.dropped {
+            t.Errorf("%s: kept %q and dropped %d, want %q and %d", tt.name, descriptions(kept), len(skipped)-len(tt.skipped), tt.want, tt.dropped)
+        }
+    }
+}
@@ -0,0 +1,154 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version (https://semver.org).
+type Version struct {
+    Major      uint64
+    Minor      uint64
+    Patch      uint64
+    Prerelease string
+    Build      string
+}
+
+var (
+    // Groups: 1=major 2=minor 3=patch 4=prerelease (optional) 5=build (optional)
+    versionRe = regexp.MustCompile(`^[vV]?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+)
+
+// Parse parses a version string such as "1.2.3", "v1.2.3-rc.1" or
+// "1.2.3+build.5". A leading "v" or "V" is accepted and discarded.
+func Parse(s string) (Version, error) {
+    m := versionRe.FindStringSubmatch(s)
+    if m == nil {
+        return Version{}, fmt.Errorf("invalid semantic version %q", s)
+    }
+    var v Version
+    var err error
+    if v.Major, err = strconv.ParseUint(m[1], 10, 64); err != nil {
+        return Version{}, fmt.Errorf("invalid semantic version %q: %w", s, err)
+    }
+    if v.Minor, err = strconv.ParseUint(m[2], 10, 64); err != nil {
+        return Version{}, fmt.Errorf("invalid semantic version %q: %w", s, err)
+    }
+    if v.Patch, err = strconv.ParseUint(m[3], 10, 64); err != nil {
+        return Version{}, fmt.Errorf("invalid semantic version %q: %w", s, err)
+    }
+    v.Prerelease = m[4]
+    v.Build = m[5]
+    return v, nil
+}
+
+// String formats the version without a "v" prefix.
+func (v Version) String() string {
+    s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+    if v.Prerelease != "" {
+        s += "-" + v.Prerelease
+    }
+    if v.Build != "" {
+        s += "+" + v.Build
+    }
+    return s
+}
+
+// IncMajor returns the next major version, e.g. 1.4.2 -> 2.0.0. A pre-release
+// of a major version is promoted to its release instead, e.g. 2.0.0-rc.1 ->
+// 2.0.0.
+func (v Version) IncMajor() Version {
+    if v.Prerelease != "" && v.Minor == 0 && v.Patch == 0 {
+        return Version{Major: v.Major}
+    }
+    return Version{Major: v.Major + 1}
+}
+
+// IncMinor returns the next minor version, e.g. 1.4.2 -> 1.5.0. A pre-release
+// of a minor version is promoted to its release instead, e.g. 1.5.0-rc.1 ->
+// 1.5.0.
+func (v Version) IncMinor() Version {
+    if v.Prerelease != "" && v.Patch == 0 {
+        return Version{Major: v.Major, Minor: v.Minor}
+    }
+    return Version{Major: v.Major, Minor: v.Minor + 1}
+}
+
+// IncPatch returns the next patch version, e.g. 1.4.2 -> 1.4.3. A pre-release
+// is promoted to its release instead, e.g. 1.5.0-rc.1 -> 1.5.0.
+func (v Version) IncPatch() Version {
+    if v.Prerelease != "" {
+        return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+    }
+    return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
+
+// Compare returns -1, 0 or +1 depending on whether a has lower, equal or
+// higher precedence than b. Build metadata is ignored, and a pre-release has
+// lower precedence than its associated normal version.
+func Compare(a, b Version) int {
+    if c := compareUint(a.Major, b.Major); c != 0 {
+        return c
+    }
+    if c := compareUint(a.Minor, b.Minor); c != 0 {
+        return c
+    }
+    if c := compareUint(a.Patch, b.Patch); c != 0 {
+        return c
+    }
+    return comparePrerelease(a.Prerelease, b.Prerelease)
+}
+
+// Less reports whether v has lower precedence than o.
+func (v Version) Less(o Version) bool {
+    return Compare(v, o) < 0
+}
+
+func compareUint(a, b uint64) int {
+    switch {
+    case a < b:
+        return -1
+    case a > b:
+        return 1
+    }
+    return 0
+}
+
+// comparePrerelease orders dot-separated pre-release identifiers: numeric
+// identifiers compare numerically and sort before alphanumeric ones, which
+// compare in ASCII order; a shorter list sorts first when all preceding
+// identifiers are equal.
+func comparePrerelease(a, b string) int {
+    if a == b {
+        return 0
+    }
+    if a == "" {
+        return 1
+    }
+    if b == "" {
+        return -1
+    }
+    ap := strings.Split(a, ".")
+    bp := strings.Split(b, ".")
+    for i := 0; i < len(ap) && i < len(bp); i++ {
+        an, aErr := strconv.ParseUint(ap[i], 10, 64)
+        bn, bErr := strconv.ParseUint(bp[i], 10, 64)
+        switch {
+        case aErr == nil && bErr == nil:
+            if c := compareUint(an, bn); c != 0 {
+                return c
+            }
+        case aErr == nil:
+            return -1
+        case bErr == nil:
+            return 1
+        default:
+            if c := strings.Compare(ap[i], bp[i]); c != 0 {
+                return c
+            }
+        }
+    }
+    return compareUint(uint64(len(ap)), uint64(len(bp)))
+}
@@ -0,0 +1,102 @@
+package semver
+
+import "testing"
+
+func TestParse_Valid(t *testing.T) {
+    tests := []struct {
+        in   string
+        want Version
+    }{
+        {"1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+        {"v0.10.0", Version{Minor: 10}},
+        {"V2.0.0-rc.1+build.7", Version{Major: 2, Prerelease: "rc.1", Build: "build.7"}},
+    }
+    for _, tt := range tests {
+        got, err := Parse(tt.in)
+        if err != nil {
+            t.Fatalf("unexpected error for %q: %v", tt.in, err)
+        }
+        if got != tt.want {
+            t.Fatalf("parse %q: got %+v, want %+v", tt.in, got, tt.want)
+        }
+    }
+}
+
+func TestParse_Invalid(t *testing.T) {
+    for _, in := range []string{"", "1.2", "01.2.3", "1.2.3-", "deploy-prod", "v1.2.3.4"} {
+        if _, err := Parse(in); err == nil {
+            t.Fatalf("expected error for %q", in)
+        }
+    }
+}
+
+func TestIncrements(t *testing.T) {
+    v, _ := Parse("1.4.2")
+    if got := v.IncMajor().String(); got != "2.0.0" {
+        t.Fatalf("IncMajor: got %s", got)
+    }
+    if got := v.IncMinor().String(); got != "1.5.0" {
+        t.Fatalf("IncMinor: got %s", got)
+    }
+    if got := v.IncPatch().String(); got != "1.4.3" {
+        t.Fatalf("IncPatch: got %s", got)
+    }
+    rc, _ := Parse("1.5.0-rc.1")
+    if got := rc.IncPatch().String(); got != "1.5.0" {
+        t.Fatalf("IncPatch on pre-release: got %s", got)
+    }
+    tests := []struct {
+        current string
+        inc     func(Version) Version
+        want    string
+    }{
+        {"1.5.0-rc.1", Version.IncMinor, "1.5.0"},
+        {"1.5.0-rc.1", Version.IncMajor, "2.0.0"},
+        {"1.5.1-rc.1", Version.IncMinor, "1.6.0"},
+        {"2.0.0-rc.1", Version.IncMajor, "2.0.0"},
+        {"2.0.0-rc.1", Version.IncMinor, "2.0.0"},
+        {"2.1.0-rc.1", Version.IncMajor, "3.0.0"},
+    }
+    for _, tt := range tests {
+        v, err := Parse(tt.current)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got := tt.inc(v).String(); got != tt.want {
+            t.Fatalf("increment of %s: got %s, want %s", tt.current, got, tt.want)
+        }
+    }
+}
+
+func TestCompare_Precedence(t *testing.T) {
+    // Ordered from lowest to highest, per the semver 2.0 spec examples.
+    ordered := []string{
+        "1.0.0-alpha",
+        "1.0.0-alpha.1",
+        "1.0.0-alpha.beta",
+        "1.0.0-beta",
+        "1.0.0-beta.2",
+        "1.0.0-beta.11",
+        "1.0.0-rc.1",
+        "1.0.0",
+        "1.0.1",
+        "1.1.0",
+        "2.0.0",
+        "10.0.0",
+    }
+    for i := 0; i < len(ordered)-1; i++ {
+        a, _ := Parse(ordered[i])
+        b, _ := Parse(ordered[i+1])
+        if Compare(a, b) != -1 || Compare(b, a) != 1 {
+            t.Fatalf("expected %s < %s", ordered[i], ordered[i+1])
+        }
+    }
+}
+
+func TestCompare_IgnoresBuildMetadata(t *testing.T) {
+    a, _ := Parse("v1.2.3+linux")
+    b, _ := Parse("1.2.3+darwin")
+    if Compare(a, b) != 0 {
+        t.Fatalf("expected equal precedence for %s and %s", a, b)
+    }
+}
@@ -20,40 +20,28 @@ const (
     modeEdit
 )
 
+// faint renders text greyed out on ANSI terminals.
+const faint = "\x1b[2m%s\x1b[0m"
+
 type model struct {
     commits      []*parser.ParsedCommit
     include      []bool
+    // skipped are the commits not in the changelog, listed after commits.
+    // Typing one with 'c' pulls it into commits.
+    skipped      []parser.Skipped
     idx          int
     mode         mode
     editBuffer   string
-    allowedTypes []string
+    types        cfg.Types
     aborted      bool
 }
 
-func initialModel(commits []*parser.ParsedCommit, configuration *cfg.Config) model {
+func initialModel(commits []*parser.ParsedCommit, skipped []parser.Skipped, configuration *cfg.Config) model {
     include := make([]bool, len(commits))
     for i := range include {
         include[i] = true
     }
-    // derive allowed types from config sections (unique, preserve order)
-    seen := map[string]bool{}
-    var types []string
-    for _, s := range configuration.Sections {
-        for _, t := range s.Types {
-            if !seen[t] {
-                seen[t] = true
-                types = append(types, t)
-            }
-        }
-    }
-    // ensure some common types are present
-    for _, t := range []string{"feat", "fix", "chore", "refactor", "docs", "test", "perf"} {
-        if !seen[t] {
-            seen[t] = true
-            types = append(types, t)
-        }
-    }
-    return model{commits: commits, include: include, allowedTypes: types}
+    return model{commits: commits, include: include, skipped: skipped, types: configuration.Types}
 }
 
 func (m model) Init() tea.Cmd { return nil }
@@ -97,7 +85,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
                 m.idx--
             }
         case "down", "j":
-            if m.idx < len(m.commits)-1 {
+            if m.idx < len(m.commits)+len(m.skipped)-1 {
+                m.idx++
+            }
+        case "K", "shift+up":
+            if m.idx > 0 && m.idx < len(m.commits) {
+                m.swap(m.idx, m.idx-1)
+                m.idx--
+            }
+        case "J", "shift+down":
+            if m.idx >= 0 && m.idx < len(m.commits)-1 {
+                m.swap(m.idx, m.idx+1)
                 m.idx++
             }
         case " ":
@@ -110,9 +108,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
                 m.editBuffer = m.commits[m.idx].Description
             }
         case "c":
-            if m.idx >= 0 && m.idx < len(m.commits) {
+            if m.idx >= len(m.commits) && m.idx < len(m.commits)+len(m.skipped) {
+                m.pullIn(m.idx - len(m.commits))
+            } else if m.idx >= 0 && m.idx < len(m.commits) {
                 cur := m.commits[m.idx].Type
-                next := nextType(cur, m.allowedTypes)
+                next := nextType(cur, m.types.Names())
                 m.commits[m.idx].Type = next
             }
         case "enter":
@@ -124,7 +124,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) View() string {
     var b strings.Builder
-    b.WriteString("Scribe - Select commits to include. Space: toggle, e: edit, c: retype, Enter: confirm, q: quit\n\n")
+    b.WriteString("Scribe - Select commits to include. Space: toggle, e: edit, c: retype, J/K: move, Enter: confirm, q: quit\n\n")
     for i, c := range m.commits {
         cursor := " "
         if i == m.idx {
@@ -145,12 +145,53 @@ func (m model) View() string {
         line := fmt.Sprintf("%s %s %s%s%s: %s\n", cursor, mark, c.Type, scope, bang, c.Description)
         b.WriteString(line)
     }
+    if len(m.skipped) > 0 {
+        b.WriteString("\n" + fmt.Sprintf(faint, "Skipped, not Conventional Commits or ignored (c: type and include)") + "\n")
+        for i, s := range m.skipped {
+            cursor := " "
+            if len(m.commits)+i == m.idx {
+                cursor = ">"
+            }
+            b.WriteString(cursor + " " + fmt.Sprintf(faint, fmt.Sprintf("[-] %s  (%s)", s.Header(), s.Reason)) + "\n")
+        }
+    }
+    if m.idx >= 0 && m.idx < len(m.commits) {
+        if desc := m.types.Description(m.commits[m.idx].Type); desc != "" {
+            b.WriteString("\n" + fmt.Sprintf(faint, m.commits[m.idx].Type+": "+desc) + "\n")
+        }
+    }
     if m.mode == modeEdit {
         b.WriteString("\nEditing description: " + m.editBuffer)
     }
     return b.String()
 }
 
+// pullIn moves the i-th skipped commit into the release. A commit that is
+// not a Conventional Commit gets the first allowed type and its header as
+// description; the cursor follows it so it can be retyped or edited.
+func (m *model) pullIn(i int) {
+    s := m.skipped[i]
+    pc := s.Parsed
+    if pc == nil {
+        typ := "chore"
+        if len(m.types) > 0 {
+            typ = m.types[0].Name
+        }
+        pc = &parser.ParsedCommit{Type: typ, Description: s.Header(), Raw: s.Raw}
+    }
+    m.skipped = append(m.skipped[:i:i], m.skipped[i+1:]...)
+    m.commits = append(m.commits, pc)
+    m.include = append(m.include, true)
+    m.idx = len(m.commits) - 1
+}
+
+// swap exchanges two commits, which sets the curated order used by sections
+// with order "curated".
+func (m *model) swap(i, j int) {
+    m.commits[i], m.commits[j] = m.commits[j], m.commits[i]
+    m.include[i], m.include[j] = m.include[j], m.include[i]
+}
+
 func nextType(cur string, types []string) string {
     if len(types) == 0 {
         return cur
@@ -164,19 +205,20 @@ func nextType(cur string, types []string) string {
 }
 
 // Run launches the interactive terminal UI.
-// It takes the commits found by the parser and the loaded config.
-// It returns the final, curated list of commits that the user has approved.
+// It takes the commits found by the parser, the skipped ones and the loaded
+// config. It returns the final, curated list of commits that the user has
+// approved and the commits that remain skipped.
 // Returns an error if the user aborts the session.
-func Run(commits []*parser.ParsedCommit, configuration *cfg.Config) ([]*parser.ParsedCommit, error) {
-    m := initialModel(commits, configuration)
+func Run(commits []*parser.ParsedCommit, skipped []parser.Skipped, configuration *cfg.Config) ([]*parser.ParsedCommit, []parser.Skipped, error) {
+    m := initialModel(commits, skipped, configuration)
     prog := tea.NewProgram(m)
     res, err := prog.Run()
     if err != nil {
-        return nil, err
+        return nil, nil, err
     }
     fm := res.(model)
     if fm.aborted {
-        return nil, ErrAborted
+        return nil, nil, ErrAborted
     }
     var out []*parser.ParsedCommit
     for i, c := range fm.commits {
@@ -184,7 +226,7 @@ func Run(commits []*parser.ParsedCommit, configuration *cfg.Config) ([]*parser.P
             out = append(out, c)
         }
     }
-    return out, nil
+    return out, fm.skipped, nil
 }
 
 
@@ -0,0 +1,68 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDetached is returned by Backend.Branch when HEAD is not on a branch.
+var ErrDetached = errors.New("HEAD is detached")
+
+// Backend performs the git operations of a release. NativeBackend uses
+// go-git and needs no git binary; ExecBackend shells out to git and therefore
+// honours the user's git configuration and hooks.
+type Backend interface {
+    // Head returns the hash HEAD points at, or "" on an unborn branch.
+    Head() (string, error)
+    // Branch returns the short name of the checked out branch, or ErrDetached.
+    Branch() (string, error)
+    TagExists(name string) (bool, error)
+    // ChangedFiles lists tracked files with staged or unstaged changes,
+    // relative to the repository root.
+    ChangedFiles() ([]string, error)
+    // StagedFiles lists the files whose changes are staged in the index,
+    // relative to the repository root.
+    StagedFiles() ([]string, error)
+    // Hooks lists the git hooks a commit would trigger.
+    Hooks() ([]string, error)
+    Add(files ...string) error
+    // Commit records the index as a new commit, signed when sign is non-nil.
+    Commit(message string, sign *Signing) error
+    // Tag tags HEAD. The tag is lightweight unless a message or signature is
+    // requested, in which case it is annotated.
+    Tag(name, message string, sign *Signing) error
+    // Reset moves the current branch back to head ("" for an unborn branch)
+    // and resets the index entries of files to match it, leaving the working
+    // tree untouched.
+    Reset(head string, files ...string) error
+    // Upstream returns the remote and the remote branch the current branch
+    // tracks, or empty strings when it has no upstream.
+    Upstream() (remote, branch string, err error)
+    // RemoteBranch returns the hash branch points at on remote, or "" when
+    // the remote has no such branch.
+    RemoteBranch(remote, branch string) (string, error)
+    // Contains reports whether commit is HEAD or one of its ancestors; a
+    // commit missing from the local repository is not contained.
+    Contains(commit string) (bool, error)
+    // Push updates remote with the "src:dst" refspecs.
+    Push(remote string, refspecs ...string) error
+}
+
+// Identity is the name and email recorded as author and committer of the
+// release commit. When empty, each backend falls back to the git config.
+type Identity struct {
+    Name  string
+    Email string
+}
+
+// NewBackend returns the backend called name: "native" (the default when name
+// is empty) or "exec".
+func NewBackend(name, repoPath string, id Identity) (Backend, error) {
+    switch name {
+    case "", "native":
+        return NewNativeBackend(repoPath, id)
+    case "exec":
+        return NewExecBackend(repoPath, id), nil
+    }
+    return nil, fmt.Errorf("unknown git backend %q (expected native or exec)", name)
+}
@@ -0,0 +1,129 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+type diffLine struct {
+    op   byte // ' ', '-' or '+'
+    text string
+}
+
+// UnifiedDiff returns the changes turning before into after in unified diff
+// format, labelled with name. It returns "" when the contents are equal.
+func UnifiedDiff(name, before, after string) string {
+    if before == after {
+        return ""
+    }
+    var lines []diffLine
+    for _, d := range diff.Do(before, after) {
+        op := byte(' ')
+        switch d.Type {
+        case diffmatchpatch.DiffDelete:
+            op = '-'
+        case diffmatchpatch.DiffInsert:
+            op = '+'
+        }
+        for _, l := range splitLines(d.Text) {
+            lines = append(lines, diffLine{op: op, text: l})
+        }
+    }
+
+    var b strings.Builder
+    fromName, toName := "a/"+name, "b/"+name
+    if before == "" {
+        fromName = "/dev/null"
+    }
+    fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+
+    // oldNo and newNo are the 1-based line numbers before lines[i].
+    oldNo, newNo := 1, 1
+    for i := 0; i < len(lines); {
+        if lines[i].op == ' ' {
+            oldNo++
+            newNo++
+            i++
+            continue
+        }
+        // Start a hunk with up to diffContext lines of leading context and
+        // extend it while changes are closer than twice the context.
+        start := i
+        for start > 0 && i-start < diffContext && lines[start-1].op == ' ' {
+            start--
+        }
+        end := i
+        for end < len(lines) {
+            if lines[end].op != ' ' {
+                end++
+                continue
+            }
+            run := end
+            for run < len(lines) && lines[run].op == ' ' {
+                run++
+            }
+            if run == len(lines) || run-end > 2*diffContext {
+                end += min(diffContext, run-end)
+                break
+            }
+            end = run
+        }
+
+        hunkOld, hunkNew := oldNo-(i-start), newNo-(i-start)
+        var oldCount, newCount int
+        var body strings.Builder
+        for _, l := range lines[start:end] {
+            if l.op != '+' {
+                oldCount++
+            }
+            if l.op != '-' {
+                newCount++
+            }
+            body.WriteByte(l.op)
+            body.WriteString(l.text)
+            body.WriteByte('\n')
+        }
+        fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(hunkOld, oldCount), hunkRange(hunkNew, newCount))
+        b.WriteString(body.String())
+
+        for _, l := range lines[i:end] {
+            if l.op != '+' {
+                oldNo++
+            }
+            if l.op != '-' {
+                newNo++
+            }
+        }
+        i = end
+    }
+    return b.String()
+}
+
+// hunkRange formats the "start,count" part of a hunk header; an empty range
+// starts at the line before the hunk, as in GNU diff.
+func hunkRange(start, count int) string {
+    if count == 0 {
+        return fmt.Sprintf("%d,0", start-1)
+    }
+    if count == 1 {
+        return fmt.Sprintf("%d", start)
+    }
+    return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+    lines := strings.SplitAfter(s, "\n")
+    if lines[len(lines)-1] == "" {
+        lines = lines[:len(lines)-1]
+    }
+    for i, l := range lines {
+        lines[i] = strings.TrimSuffix(l, "\n")
+    }
+    return lines
+}
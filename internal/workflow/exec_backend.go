@@ -0,0 +1,256 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ExecBackend runs the git binary found in PATH.
+type ExecBackend struct {
+    repoPath string
+    identity Identity
+}
+
+// NewExecBackend returns a Backend that shells out to git in repoPath. A
+// non-empty identity overrides user.name and user.email for the commit.
+func NewExecBackend(repoPath string, id Identity) *ExecBackend {
+    return &ExecBackend{repoPath: repoPath, identity: id}
+}
+
+func (b *ExecBackend) Head() (string, error) {
+    head, err := git(b.repoPath, "rev-parse", "-q", "--verify", "HEAD")
+    if err != nil {
+        // An unborn branch has no HEAD commit yet.
+        if _, symErr := git(b.repoPath, "symbolic-ref", "-q", "HEAD"); symErr == nil {
+            return "", nil
+        }
+        return "", err
+    }
+    return head, nil
+}
+
+func (b *ExecBackend) Branch() (string, error) {
+    branch, err := git(b.repoPath, "symbolic-ref", "-q", "--short", "HEAD")
+    if err != nil {
+        return "", ErrDetached
+    }
+    return branch, nil
+}
+
+func (b *ExecBackend) TagExists(name string) (bool, error) {
+    _, err := git(b.repoPath, "rev-parse", "-q", "--verify", "refs/tags/"+name)
+    // A missing ref is the only failure rev-parse -q --verify reports with
+    // exit status 1 and nothing on stderr.
+    var gerr *gitError
+    var exit *exec.ExitError
+    if errors.As(err, &gerr) && gerr.stderr == "" && errors.As(err, &exit) && exit.ExitCode() == 1 {
+        return false, nil
+    }
+    return err == nil, err
+}
+
+func (b *ExecBackend) ChangedFiles() ([]string, error) {
+    status, err := git(b.repoPath, "status", "--porcelain", "-z", "--untracked-files=no")
+    if err != nil {
+        return nil, err
+    }
+    var files []string
+    entries := strings.Split(status, "\x00")
+    for i := 0; i < len(entries); i++ {
+        entry := entries[i]
+        if len(entry) < 4 {
+            continue
+        }
+        if entry[0] == 'R' || entry[0] == 'C' {
+            // Renames and copies are followed by the original path.
+            i++
+        }
+        files = append(files, entry[3:])
+    }
+    return files, nil
+}
+
+func (b *ExecBackend) StagedFiles() ([]string, error) {
+    out, err := git(b.repoPath, "diff", "--cached", "--name-only", "-z")
+    if err != nil {
+        return nil, err
+    }
+    var files []string
+    for _, file := range strings.Split(out, "\x00") {
+        if file != "" {
+            files = append(files, file)
+        }
+    }
+    return files, nil
+}
+
+// Hooks honours core.hooksPath and only reports executable hooks.
+func (b *ExecBackend) Hooks() ([]string, error) {
+    dir, err := git(b.repoPath, "rev-parse", "--git-path", "hooks")
+    if err != nil {
+        return nil, err
+    }
+    if !filepath.IsAbs(dir) {
+        dir = filepath.Join(b.repoPath, dir)
+    }
+    var hooks []string
+    for _, name := range []string{"pre-commit", "prepare-commit-msg", "commit-msg", "post-commit"} {
+        p := filepath.Join(dir, name)
+        if info, err := os.Stat(p); err == nil && !info.IsDir() && info.Mode()&0o111 != 0 {
+            hooks = append(hooks, p)
+        }
+    }
+    return hooks, nil
+}
+
+func (b *ExecBackend) Add(files ...string) error {
+    _, err := git(b.repoPath, append([]string{"add", "--"}, files...)...)
+    return err
+}
+
+func (b *ExecBackend) Commit(message string, sign *Signing) error {
+    var args []string
+    if b.identity.Name != "" {
+        args = append(args, "-c", "user.name="+b.identity.Name)
+    }
+    if b.identity.Email != "" {
+        args = append(args, "-c", "user.email="+b.identity.Email)
+    }
+    args = append(signingConfig(sign), args...)
+    args = append(args, "commit", "-m", message)
+    if sign != nil {
+        args = append(args, "-S"+sign.Key)
+    }
+    _, err := git(b.repoPath, args...)
+    return err
+}
+
+func (b *ExecBackend) Tag(name, message string, sign *Signing) error {
+    if message == "" && sign == nil {
+        _, err := git(b.repoPath, "tag", name)
+        return err
+    }
+    f, err := os.CreateTemp("", "scribe-tag-*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(f.Name())
+    _, err = f.WriteString(tagMessage(name, message))
+    if cerr := f.Close(); err == nil {
+        err = cerr
+    }
+    if err != nil {
+        return err
+    }
+
+    var args []string
+    if b.identity.Name != "" {
+        args = append(args, "-c", "user.name="+b.identity.Name)
+    }
+    if b.identity.Email != "" {
+        args = append(args, "-c", "user.email="+b.identity.Email)
+    }
+    args = append(signingConfig(sign), args...)
+    args = append(args, "tag", "--cleanup=verbatim", "-F", f.Name())
+    switch {
+    case sign == nil:
+        args = append(args, "-a")
+    case sign.Key != "":
+        args = append(args, "-u", sign.Key)
+    default:
+        args = append(args, "-s")
+    }
+    _, err = git(b.repoPath, append(args, name)...)
+    return err
+}
+
+// signingConfig returns the -c options selecting the signature format.
+func signingConfig(sign *Signing) []string {
+    if sign == nil || sign.Format == "" {
+        return nil
+    }
+    return []string{"-c", "gpg.format=" + sign.Format}
+}
+
+func (b *ExecBackend) Reset(head string, files ...string) error {
+    if head == "" {
+        if _, err := git(b.repoPath, "update-ref", "-d", "HEAD"); err != nil {
+            return err
+        }
+        _, err := git(b.repoPath, append([]string{"rm", "-q", "--cached", "--ignore-unmatch", "--"}, files...)...)
+        return err
+    }
+    if _, err := git(b.repoPath, "reset", "-q", "--soft", head); err != nil {
+        return err
+    }
+    _, err := git(b.repoPath, append([]string{"reset", "-q", head, "--"}, files...)...)
+    return err
+}
+
+func (b *ExecBackend) Upstream() (string, string, error) {
+    branch, err := b.Branch()
+    if err != nil {
+        return "", "", err
+    }
+    out, err := git(b.repoPath, "for-each-ref", "--format=%(upstream:remotename)%00%(upstream:remoteref)", "refs/heads/"+branch)
+    if err != nil {
+        return "", "", err
+    }
+    remote, ref, _ := strings.Cut(out, "\x00")
+    return remote, strings.TrimPrefix(ref, "refs/heads/"), nil
+}
+
+func (b *ExecBackend) RemoteBranch(remote, branch string) (string, error) {
+    out, err := git(b.repoPath, "ls-remote", "--heads", remote, "refs/heads/"+branch)
+    if err != nil {
+        return "", err
+    }
+    hash, _, _ := strings.Cut(out, "\t")
+    return hash, nil
+}
+
+func (b *ExecBackend) Contains(commit string) (bool, error) {
+    if _, err := git(b.repoPath, "cat-file", "-e", commit+"^{commit}"); err != nil {
+        return false, nil
+    }
+    _, err := git(b.repoPath, "merge-base", "--is-ancestor", commit, "HEAD")
+    return err == nil, nil
+}
+
+func (b *ExecBackend) Push(remote string, refspecs ...string) error {
+    _, err := git(b.repoPath, append([]string{"push", "--quiet", remote}, refspecs...)...)
+    return err
+}
+
+// gitError is a failed git command with what it wrote to stderr.
+type gitError struct {
+    args   []string
+    err    error
+    stderr string
+}
+
+func (e *gitError) Error() string {
+    return fmt.Sprintf("git %s failed: %v: %s", strings.Join(e.args, " "), e.err, e.stderr)
+}
+
+func (e *gitError) Unwrap() error {
+    return e.err
+}
+
+// git runs a git command in repoPath and returns its standard output without
+// the trailing newline. A failure is a *gitError.
+func git(repoPath string, args ...string) (string, error) {
+    cmd := exec.Command("git", args...)
+    cmd.Dir = repoPath
+    var stderr strings.Builder
+    cmd.Stderr = &stderr
+    out, err := cmd.Output()
+    if err != nil {
+        return "", &gitError{args: args, err: err, stderr: strings.TrimSpace(stderr.String())}
+    }
+    return strings.TrimRight(string(out), "\n"), nil
+}
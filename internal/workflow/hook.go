@@ -0,0 +1,59 @@
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	gitv5 "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// HooksDir returns the directory git runs hooks from: core.hooksPath when
+// set (relative to the working tree), otherwise the hooks directory of the
+// repository's git dir.
+func HooksDir(repoPath string) (string, error) {
+    repo, err := gitv5.PlainOpenWithOptions(repoPath, &gitv5.PlainOpenOptions{DetectDotGit: true})
+    if err != nil {
+        return "", err
+    }
+    c, err := repo.Config()
+    if err != nil {
+        return "", err
+    }
+    if dir := c.Raw.Section("core").Option("hooksPath"); dir != "" {
+        if filepath.IsAbs(dir) {
+            return dir, nil
+        }
+        wt, err := repo.Worktree()
+        if err != nil {
+            return "", err
+        }
+        return filepath.Join(wt.Filesystem.Root(), dir), nil
+    }
+    fs, ok := repo.Storer.(*filesystem.Storage)
+    if !ok {
+        return "", fmt.Errorf("%s: repository has no hooks directory", repoPath)
+    }
+    return filepath.Join(fs.Filesystem().Root(), "hooks"), nil
+}
+
+// InstallHook writes an executable git hook called name, e.g. "commit-msg",
+// and returns its path. An existing hook is only replaced when force is set.
+func InstallHook(repoPath, name, script string, force bool) (string, error) {
+    dir, err := HooksDir(repoPath)
+    if err != nil {
+        return "", err
+    }
+    file := filepath.Join(dir, name)
+    if _, err := os.Stat(file); err == nil && !force {
+        return "", fmt.Errorf("%s already exists; pass --force to replace it", file)
+    }
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        return "", err
+    }
+    if err := WriteFileAtomic(file, []byte(script)); err != nil {
+        return "", err
+    }
+    return file, os.Chmod(file, 0o755)
+}
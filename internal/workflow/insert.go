@@ -0,0 +1,201 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/felipevolpatto/scribe/internal/semver"
+)
+
+// ErrVersionExists is returned when the changelog already has a section for
+// the version being released.
+var ErrVersionExists = errors.New("changelog already has a section for this version")
+
+// releaseHeadingRe matches a release heading and captures its version, e.g.
+// "1.2.0" in "## [1.2.0](https://...) - 2024-05-01" or "v1.2.0" in
+// "## v1.2.0 - 2024-05-01".
+var releaseHeadingRe = regexp.MustCompile(`^##\s+\[?([^\]\s(]+)\]?`)
+
+// InsertRelease returns content with notes inserted after the title and
+// preamble, i.e. before the first "## " heading of a release (one whose
+// version parses as a semantic version, or "Unreleased"), or before the link
+// reference definitions at the end of the file when there is none. The
+// entries of an "Unreleased" section are dropped, since the notes cover them,
+// and its heading is kept, empty, above the new release so that an
+// "[Unreleased]" link definition still has a heading. When content already
+// has a section for version, the result is an error wrapping ErrVersionExists
+// unless force is set, in which case that section is replaced. An empty
+// version skips the check.
+func InsertRelease(content, notes, version string, force bool) (string, error) {
+    lines := strings.SplitAfter(content, "\n")
+    if lines[len(lines)-1] == "" {
+        lines = lines[:len(lines)-1]
+    }
+
+    // The link reference definitions at the end of the file stay there.
+    tail := len(lines)
+    for tail > 0 && (strings.TrimSpace(lines[tail-1]) == "" || linkDefinitionRe.MatchString(lines[tail-1])) {
+        tail--
+    }
+    if strings.TrimSpace(strings.Join(lines[tail:], "")) == "" {
+        tail = len(lines)
+    }
+    suffix := strings.Join(lines[tail:], "")
+    lines = lines[:tail]
+
+    // Find the level-2 headings outside fenced code blocks.
+    type section struct{ start, end int }
+    var sections []section
+    fenced := false
+    for i, line := range lines {
+        trimmed := strings.TrimSpace(line)
+        if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+            fenced = !fenced
+            continue
+        }
+        if !fenced && strings.HasPrefix(line, "## ") {
+            if n := len(sections); n > 0 {
+                sections[n-1].end = i
+            }
+            sections = append(sections, section{start: i, end: len(lines)})
+        }
+    }
+
+    insertAt := -1
+    removed := map[int]bool{}
+    replaced := false
+    for _, s := range sections {
+        m := releaseHeadingRe.FindStringSubmatch(lines[s.start])
+        if m == nil {
+            continue
+        }
+        unreleased := strings.EqualFold(m[1], "unreleased")
+        if _, err := semver.Parse(path.Base(m[1])); err != nil && !unreleased {
+            // Part of the preamble, e.g. "## Versioning".
+            continue
+        }
+        if insertAt == -1 {
+            insertAt = s.start
+        }
+        from := s.start
+        switch {
+        case unreleased:
+            from++
+        case version != "" && sameVersion(m[1], version):
+            if !force {
+                return "", fmt.Errorf("%s: %w", version, ErrVersionExists)
+            }
+        default:
+            continue
+        }
+        if !replaced {
+            insertAt, replaced = from, true
+        }
+        for i := from; i < s.end; i++ {
+            removed[i] = true
+        }
+    }
+    if insertAt == -1 {
+        insertAt = len(lines)
+    }
+
+    before := strings.Join(lines[:insertAt], "")
+    var after strings.Builder
+    for i := insertAt; i < len(lines); i++ {
+        if !removed[i] {
+            after.WriteString(lines[i])
+        }
+    }
+    // Keep a blank line around the new release.
+    if before != "" {
+        before = strings.TrimRight(before, "\n") + "\n\n"
+    }
+    if after.Len() > 0 {
+        notes = strings.TrimRight(notes, "\n") + "\n\n"
+    }
+    out := before + notes + after.String()
+    if suffix != "" {
+        out = strings.TrimRight(out, "\n") + "\n\n" + strings.TrimLeft(suffix, "\n")
+    }
+    return out, nil
+}
+
+// LinkDefinition is a Markdown link reference definition such as
+// "[1.2.0]: https://github.com/org/repo/compare/v1.1.0...v1.2.0".
+type LinkDefinition struct {
+    Label string
+    URL   string
+    // ReplaceOnly updates an existing definition but never adds one.
+    ReplaceOnly bool
+}
+
+// linkDefinitionRe matches a link reference definition.
+// Groups: 1=label
+var linkDefinitionRe = regexp.MustCompile(`^\[([^\]]+)\]:\s*\S`)
+
+// UpdateLinkDefinitions rewrites the definitions in content whose label
+// matches one of defs (case-insensitively) and adds the others. New
+// definitions go before the first definition of a version, so that the list
+// stays newest first below any "[Unreleased]" entry, or at the end of the
+// file when there is none.
+func UpdateLinkDefinitions(content string, defs []LinkDefinition) string {
+    lines := strings.SplitAfter(content, "\n")
+    if lines[len(lines)-1] == "" {
+        lines = lines[:len(lines)-1]
+    }
+    var added []string
+    insertAt, last := -1, -1
+    for _, d := range defs {
+        line := fmt.Sprintf("[%s]: %s\n", d.Label, d.URL)
+        found := false
+        for i, l := range lines {
+            if m := linkDefinitionRe.FindStringSubmatch(l); m != nil && strings.EqualFold(m[1], d.Label) {
+                lines[i], found = line, true
+            }
+        }
+        if !found && !d.ReplaceOnly {
+            added = append(added, line)
+        }
+    }
+    if len(added) == 0 {
+        return strings.Join(lines, "")
+    }
+    for i, l := range lines {
+        m := linkDefinitionRe.FindStringSubmatch(l)
+        if m == nil {
+            continue
+        }
+        last = i
+        if _, err := semver.Parse(path.Base(m[1])); err == nil && insertAt == -1 {
+            insertAt = i
+        }
+    }
+    switch {
+    case insertAt >= 0:
+    case last >= 0:
+        insertAt = last + 1
+    default:
+        out := strings.TrimRight(strings.Join(lines, ""), "\n")
+        if out != "" {
+            out += "\n\n"
+        }
+        return out + strings.Join(added, "")
+    }
+    out := append(append(append([]string{}, lines[:insertAt]...), added...), lines[insertAt:]...)
+    return strings.Join(out, "")
+}
+
+// sameVersion compares a heading version with the released one, ignoring a
+// "v" prefix and any tag prefix such as "api/".
+func sameVersion(heading, version string) bool {
+    heading, version = path.Base(heading), path.Base(version)
+    a, errA := semver.Parse(heading)
+    b, errB := semver.Parse(version)
+    if errA != nil || errB != nil {
+        return heading == version
+    }
+    return semver.Compare(a, b) == 0 && a.Build == b.Build
+}
@@ -0,0 +1,341 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gitv5 "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// NativeBackend implements Backend with go-git. It does not run git hooks.
+type NativeBackend struct {
+    repo     *gitv5.Repository
+    identity Identity
+}
+
+// NewNativeBackend opens the repository at repoPath. The commit identity is
+// id, or else user.name and user.email from the repository and global git
+// configuration.
+func NewNativeBackend(repoPath string, id Identity) (*NativeBackend, error) {
+    repo, err := gitv5.PlainOpen(repoPath)
+    if err != nil {
+        return nil, err
+    }
+    return &NativeBackend{repo: repo, identity: id}, nil
+}
+
+func (b *NativeBackend) Head() (string, error) {
+    ref, err := b.repo.Head()
+    if errors.Is(err, plumbing.ErrReferenceNotFound) {
+        return "", nil
+    }
+    if err != nil {
+        return "", err
+    }
+    return ref.Hash().String(), nil
+}
+
+func (b *NativeBackend) Branch() (string, error) {
+    ref, err := b.repo.Storer.Reference(plumbing.HEAD)
+    if err != nil {
+        return "", err
+    }
+    if ref.Type() != plumbing.SymbolicReference || !ref.Target().IsBranch() {
+        return "", ErrDetached
+    }
+    return ref.Target().Short(), nil
+}
+
+func (b *NativeBackend) TagExists(name string) (bool, error) {
+    _, err := b.repo.Reference(plumbing.NewTagReferenceName(name), false)
+    if errors.Is(err, plumbing.ErrReferenceNotFound) {
+        return false, nil
+    }
+    return err == nil, err
+}
+
+func (b *NativeBackend) ChangedFiles() ([]string, error) {
+    wt, err := b.repo.Worktree()
+    if err != nil {
+        return nil, err
+    }
+    status, err := wt.Status()
+    if err != nil {
+        return nil, err
+    }
+    var files []string
+    for file, s := range status {
+        if s.Staging == gitv5.Untracked {
+            continue
+        }
+        if s.Staging != gitv5.Unmodified || s.Worktree != gitv5.Unmodified {
+            files = append(files, file)
+        }
+    }
+    return files, nil
+}
+
+func (b *NativeBackend) StagedFiles() ([]string, error) {
+    wt, err := b.repo.Worktree()
+    if err != nil {
+        return nil, err
+    }
+    status, err := wt.Status()
+    if err != nil {
+        return nil, err
+    }
+    var files []string
+    for file, s := range status {
+        if s.Staging != gitv5.Unmodified && s.Staging != gitv5.Untracked {
+            files = append(files, file)
+        }
+    }
+    return files, nil
+}
+
+// Hooks always returns nothing: go-git does not run git hooks.
+func (b *NativeBackend) Hooks() ([]string, error) {
+    return nil, nil
+}
+
+func (b *NativeBackend) Add(files ...string) error {
+    wt, err := b.repo.Worktree()
+    if err != nil {
+        return err
+    }
+    for _, f := range files {
+        if _, err := wt.Add(filepath.ToSlash(f)); err != nil {
+            return fmt.Errorf("add %s: %w", f, err)
+        }
+    }
+    return nil
+}
+
+func (b *NativeBackend) Commit(message string, sign *Signing) error {
+    sig, err := b.signature()
+    if err != nil {
+        return err
+    }
+    opts := &gitv5.CommitOptions{Author: sig, Committer: sig}
+    if sign != nil {
+        signer, err := b.signer(*sign)
+        if err != nil {
+            return err
+        }
+        opts.Signer = signer
+    }
+    wt, err := b.repo.Worktree()
+    if err != nil {
+        return err
+    }
+    _, err = wt.Commit(message, opts)
+    return err
+}
+
+func (b *NativeBackend) Tag(name, message string, sign *Signing) error {
+    head, err := b.repo.Head()
+    if err != nil {
+        return err
+    }
+    if message == "" && sign == nil {
+        _, err = b.repo.CreateTag(name, head.Hash(), nil)
+        return err
+    }
+    if exists, err := b.TagExists(name); err != nil {
+        return err
+    } else if exists {
+        return gitv5.ErrTagExists
+    }
+    sig, err := b.signature()
+    if err != nil {
+        return err
+    }
+
+    // Built by hand rather than with CreateTag, which can only sign with an
+    // in-memory OpenPGP key.
+    tag := &object.Tag{
+        Name:       name,
+        Tagger:     *sig,
+        Message:    tagMessage(name, message),
+        TargetType: plumbing.CommitObject,
+        Target:     head.Hash(),
+    }
+    if sign != nil {
+        signer, err := b.signer(*sign)
+        if err != nil {
+            return err
+        }
+        unsigned := &plumbing.MemoryObject{}
+        if err := tag.EncodeWithoutSignature(unsigned); err != nil {
+            return err
+        }
+        r, err := unsigned.Reader()
+        if err != nil {
+            return err
+        }
+        signature, err := signer.Sign(r)
+        if err != nil {
+            return err
+        }
+        tag.PGPSignature = string(signature)
+    }
+    obj := b.repo.Storer.NewEncodedObject()
+    if err := tag.Encode(obj); err != nil {
+        return err
+    }
+    hash, err := b.repo.Storer.SetEncodedObject(obj)
+    if err != nil {
+        return err
+    }
+    return b.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewTagReferenceName(name), hash))
+}
+
+func (b *NativeBackend) Reset(head string, files ...string) error {
+    if head != "" {
+        wt, err := b.repo.Worktree()
+        if err != nil {
+            return err
+        }
+        return wt.Reset(&gitv5.ResetOptions{Commit: plumbing.NewHash(head), Mode: gitv5.MixedReset, Files: slashPaths(files)})
+    }
+
+    // Unborn branch: drop the branch ref and the index entries.
+    ref, err := b.repo.Storer.Reference(plumbing.HEAD)
+    if err != nil {
+        return err
+    }
+    if ref.Type() == plumbing.SymbolicReference {
+        if err := b.repo.Storer.RemoveReference(ref.Target()); err != nil {
+            return err
+        }
+    }
+    idx, err := b.repo.Storer.Index()
+    if err != nil {
+        return err
+    }
+    for _, f := range slashPaths(files) {
+        if _, err := idx.Remove(f); err != nil && !errors.Is(err, index.ErrEntryNotFound) {
+            return err
+        }
+    }
+    return b.repo.Storer.SetIndex(idx)
+}
+
+func (b *NativeBackend) Upstream() (string, string, error) {
+    branch, err := b.Branch()
+    if err != nil {
+        return "", "", err
+    }
+    c, err := b.repo.Config()
+    if err != nil {
+        return "", "", err
+    }
+    up, ok := c.Branches[branch]
+    if !ok || up.Remote == "" {
+        return "", "", nil
+    }
+    return up.Remote, strings.TrimPrefix(up.Merge.String(), "refs/heads/"), nil
+}
+
+func (b *NativeBackend) RemoteBranch(remote, branch string) (string, error) {
+    rem, err := b.repo.Remote(remote)
+    if err != nil {
+        return "", fmt.Errorf("remote %q: %w", remote, err)
+    }
+    refs, err := rem.List(&gitv5.ListOptions{})
+    if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+        return "", nil
+    }
+    if err != nil {
+        return "", err
+    }
+    want := plumbing.NewBranchReferenceName(branch)
+    for _, ref := range refs {
+        if ref.Name() == want {
+            return ref.Hash().String(), nil
+        }
+    }
+    return "", nil
+}
+
+func (b *NativeBackend) Contains(commit string) (bool, error) {
+    head, err := b.repo.Head()
+    if err != nil {
+        return false, err
+    }
+    hash := plumbing.NewHash(commit)
+    if hash == head.Hash() {
+        return true, nil
+    }
+    c, err := b.repo.CommitObject(hash)
+    if errors.Is(err, plumbing.ErrObjectNotFound) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    headCommit, err := b.repo.CommitObject(head.Hash())
+    if err != nil {
+        return false, err
+    }
+    return c.IsAncestor(headCommit)
+}
+
+func (b *NativeBackend) Push(remote string, refspecs ...string) error {
+    specs := make([]config.RefSpec, len(refspecs))
+    for i, s := range refspecs {
+        specs[i] = config.RefSpec(s)
+    }
+    err := b.repo.Push(&gitv5.PushOptions{RemoteName: remote, RefSpecs: specs})
+    if errors.Is(err, gitv5.NoErrAlreadyUpToDate) {
+        return nil
+    }
+    return err
+}
+
+// signer returns the signer for sign, completed from the git configuration.
+func (b *NativeBackend) signer(sign Signing) (gitv5.Signer, error) {
+    sign, err := resolveSigning(b.repo, sign)
+    if err != nil {
+        return nil, err
+    }
+    return execSigner{sign}, nil
+}
+
+// signature resolves the commit identity: the configured Identity first, then
+// the repository and global git configuration.
+func (b *NativeBackend) signature() (*object.Signature, error) {
+    name, email := b.identity.Name, b.identity.Email
+    if name == "" || email == "" {
+        c, err := b.repo.ConfigScoped(config.GlobalScope)
+        if err != nil {
+            return nil, err
+        }
+        if name == "" {
+            name = c.User.Name
+        }
+        if email == "" {
+            email = c.User.Email
+        }
+    }
+    if name == "" || email == "" {
+        return nil, errors.New("no commit identity: set user.name and user.email in git config or identity in .scribe.yml")
+    }
+    return &object.Signature{Name: name, Email: email, When: time.Now()}, nil
+}
+
+func slashPaths(files []string) []string {
+    out := make([]string, len(files))
+    for i, f := range files {
+        out[i] = filepath.ToSlash(f)
+    }
+    return out
+}
@@ -0,0 +1,154 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// StepError reports the release step that failed and whether undoing the
+// earlier steps succeeded.
+type StepError struct {
+    Step        string
+    Err         error
+    RollbackErr error
+}
+
+func (e *StepError) Error() string {
+    msg := fmt.Sprintf("release step %q failed: %v", e.Step, e.Err)
+    if e.Step == "preflight" || e.Step == "git push" {
+        return msg
+    }
+    if e.RollbackErr != nil {
+        return msg + fmt.Sprintf("; rollback failed, the repository may be left half-released: %v", e.RollbackErr)
+    }
+    return msg + "; changes were rolled back"
+}
+
+func (e *StepError) Unwrap() error { return e.Err }
+
+// Preflight verifies that the release can be made: HEAD is on a branch, the
+// branch is allowed, neither the tag nor a changelog section for the version
+// exists yet (unless Force), the working tree has no changes besides the
+// changelog and AllowDirty files, the changes to AllowDirty files are not
+// staged (the release commit records the whole index) and, when pushing, the
+// branch is not behind the remote. All failed checks are reported together.
+func (r Release) Preflight() error {
+    b, err := r.backend()
+    if err != nil {
+        return err
+    }
+    var errs []error
+
+    branch, branchErr := b.Branch()
+    if errors.Is(branchErr, ErrDetached) {
+        errs = append(errs, errors.New("HEAD is detached; check out a branch to release from"))
+    } else if branchErr != nil {
+        errs = append(errs, branchErr)
+    } else if !matchAny(r.Branches, branch) {
+        errs = append(errs, fmt.Errorf("branch %q is not allowed for releases (allowed: %s)", branch, strings.Join(r.Branches, ", ")))
+    }
+
+    if exists, err := b.TagExists(r.Tag); err != nil {
+        errs = append(errs, err)
+    } else if exists {
+        errs = append(errs, fmt.Errorf("tag %s already exists", r.Tag))
+    }
+
+    if r.Push && branchErr == nil {
+        if err := r.checkRemote(b); err != nil {
+            errs = append(errs, err)
+        }
+    }
+
+    if existing, err := os.ReadFile(filepath.Join(r.RepoPath, filepath.FromSlash(r.Changelog))); err == nil {
+        if _, err := InsertRelease(string(existing), r.Notes, r.Version, r.Force); errors.Is(err, ErrVersionExists) {
+            errs = append(errs, fmt.Errorf("%s already has a section for %s; use --force to replace it", r.Changelog, r.Version))
+        }
+    } else if !os.IsNotExist(err) {
+        errs = append(errs, err)
+    }
+
+    changed, err := b.ChangedFiles()
+    if err != nil {
+        errs = append(errs, err)
+    } else {
+        allowed := append([]string{r.Changelog}, r.AllowDirty...)
+        var dirty []string
+        for _, file := range changed {
+            if !allowedPath(allowed, file) {
+                dirty = append(dirty, file)
+            }
+        }
+        sort.Strings(dirty)
+        if len(dirty) > 0 {
+            errs = append(errs, fmt.Errorf("working tree has uncommitted changes: %s", strings.Join(dirty, ", ")))
+        }
+    }
+
+    staged, err := b.StagedFiles()
+    if err != nil {
+        errs = append(errs, err)
+    } else {
+        var commit []string
+        for _, file := range staged {
+            if allowedPath(r.AllowDirty, file) && !allowedPath([]string{r.Changelog}, file) {
+                commit = append(commit, file)
+            }
+        }
+        sort.Strings(commit)
+        if len(commit) > 0 {
+            errs = append(errs, fmt.Errorf("staged changes would be committed with the release: %s; unstage them first", strings.Join(commit, ", ")))
+        }
+    }
+    return errors.Join(errs...)
+}
+
+// checkRemote fails when the remote branch Push would update has commits that
+// HEAD does not contain, since the push would then be rejected.
+func (r Release) checkRemote(b Backend) error {
+    remote, branch, _, err := r.pushPlan(b)
+    if err != nil {
+        return err
+    }
+    hash, err := b.RemoteBranch(remote, branch)
+    if err != nil {
+        return fmt.Errorf("cannot read %s/%s: %w", remote, branch, err)
+    }
+    if hash == "" {
+        return nil
+    }
+    ok, err := b.Contains(hash)
+    if err != nil {
+        return err
+    }
+    if !ok {
+        return fmt.Errorf("branch is behind %s/%s; pull before releasing", remote, branch)
+    }
+    return nil
+}
+
+func matchAny(patterns []string, name string) bool {
+    if len(patterns) == 0 {
+        return true
+    }
+    for _, p := range patterns {
+        if ok, _ := path.Match(p, name); ok {
+            return true
+        }
+    }
+    return false
+}
+
+func allowedPath(allowed []string, file string) bool {
+    for _, a := range allowed {
+        if path.Clean(filepath.ToSlash(a)) == file {
+            return true
+        }
+    }
+    return false
+}
@@ -2,9 +2,12 @@ package workflow
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"strings"
 )
 
 // PrependToFile adds the new changelog content to the top of CHANGELOG.md.
@@ -17,24 +20,316 @@ func PrependToFile(filePath string, content string) error {
     var buf bytes.Buffer
     buf.WriteString(content)
     buf.Write(existing)
-    return os.WriteFile(filePath, buf.Bytes(), 0o644)
+    return WriteFileAtomic(filePath, buf.Bytes())
 }
 
-// CommitAndTag executes 'git add CHANGELOG.md', 'git commit', and 'git tag'.
-func CommitAndTag(repoPath, version string) error {
-    cmds := [][]string{
-        {"git", "add", "CHANGELOG.md"},
-        {"git", "commit", "-m", fmt.Sprintf("chore(release): %s", version)},
-        {"git", "tag", version},
+// WriteFileAtomic replaces filePath with data by writing a temporary file in
+// the same directory and renaming it, so readers never observe a partially
+// written file. The permissions of an existing file are preserved.
+func WriteFileAtomic(filePath string, data []byte) error {
+    mode := os.FileMode(0o644)
+    if info, err := os.Stat(filePath); err == nil {
+        mode = info.Mode().Perm()
     }
-    for _, c := range cmds {
-        cmd := exec.Command(c[0], c[1:]...)
-        cmd.Dir = repoPath
-        if out, err := cmd.CombinedOutput(); err != nil {
-            return fmt.Errorf("%s failed: %v: %s", c, err, string(out))
+    tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+    if _, err := tmp.Write(data); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Sync(); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    if err := os.Chmod(tmp.Name(), mode); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), filePath)
+}
+
+// CommitAndTag executes 'git add' for the given files (CHANGELOG.md when none
+// are given), 'git commit', and 'git tag' with the git binary.
+func CommitAndTag(repoPath, version string, files ...string) error {
+    if len(files) == 0 {
+        files = []string{"CHANGELOG.md"}
+    }
+    b := NewExecBackend(repoPath, Identity{})
+    if err := b.Add(files...); err != nil {
+        return err
+    }
+    if err := b.Commit(fmt.Sprintf("chore(release): %s", version), nil); err != nil {
+        return err
+    }
+    return b.Tag(version, "", nil)
+}
+
+// Release describes everything 'scribe release' changes in a repository: the
+// notes prepended to the changelog file, the release commit and the tag.
+type Release struct {
+    RepoPath string
+    // Changelog is the changelog file, relative to RepoPath.
+    Changelog string
+    // Notes is the rendered release section.
+    Notes string
+    Tag   string
+    // Version is the released version, used to find an existing section for
+    // it in the changelog. Empty skips that check.
+    Version string
+    // Force replaces an existing section for Version instead of refusing
+    // the release.
+    Force bool
+    // Links are link reference definitions to add or update in the
+    // changelog, e.g. the compare link of a Keep a Changelog heading.
+    Links []LinkDefinition
+    // TagMessage makes the tag annotated with this message; empty creates a
+    // lightweight tag unless Sign is set.
+    TagMessage string
+    // Sign signs the release commit and tag; nil leaves both unsigned.
+    Sign *Signing
+    // Branches restricts the branches a release may be made from; each entry
+    // is a glob such as "main" or "release/*". Empty allows any branch.
+    Branches []string
+    // AllowDirty lists files, relative to RepoPath, that may have uncommitted
+    // changes. The changelog file is always allowed.
+    AllowDirty []string
+    // Push pushes the release commit to the upstream of the current branch
+    // and the tag to Remote once both are created.
+    Push bool
+    // Remote is the remote Push uses; empty selects the upstream's remote,
+    // then origin.
+    Remote string
+    // Backend performs the git operations; nil selects NativeBackend with
+    // the identity from the git configuration.
+    Backend Backend
+}
+
+func (r Release) backend() (Backend, error) {
+    if r.Backend != nil {
+        return r.Backend, nil
+    }
+    return NewNativeBackend(r.RepoPath, Identity{})
+}
+
+// CommitMessage returns the message of the release commit.
+func (r Release) CommitMessage() string {
+    return fmt.Sprintf("chore(release): %s", r.Tag)
+}
+
+// Run checks the preconditions, prepends the notes to the changelog, then
+// commits and tags. If any step fails, the steps already performed are undone
+// and a *StepError naming the failed step is returned.
+func (r Release) Run() error {
+    if err := r.Preflight(); err != nil {
+        return &StepError{Step: "preflight", Err: err}
+    }
+    b, err := r.backend()
+    if err != nil {
+        return err
+    }
+
+    file := filepath.Join(r.RepoPath, filepath.FromSlash(r.Changelog))
+    original, readErr := os.ReadFile(file)
+    existed := readErr == nil
+    origHead, err := b.Head()
+    if err != nil {
+        return &StepError{Step: "preflight", Err: err}
+    }
+
+    var staged bool
+    fail := func(step string, err error) error {
+        return &StepError{Step: step, Err: err, RollbackErr: r.rollback(b, file, original, existed, origHead, staged)}
+    }
+
+    updated, err := r.apply(string(original))
+    if err == nil {
+        err = WriteFileAtomic(file, []byte(updated))
+    }
+    if err != nil {
+        return fail("write changelog", err)
+    }
+    if err := b.Add(r.Changelog); err != nil {
+        return fail("git add", err)
+    }
+    staged = true
+    if err := b.Commit(r.CommitMessage(), r.Sign); err != nil {
+        return fail("git commit", err)
+    }
+    if err := b.Tag(r.Tag, r.TagMessage, r.Sign); err != nil {
+        return fail("git tag", err)
+    }
+    if r.Push {
+        // The local release is complete; a rejected push leaves it in place
+        // so it can be pushed again once the remote is sorted out.
+        remote, _, refspecs, err := r.pushPlan(b)
+        if err == nil {
+            err = b.Push(remote, refspecs...)
+        }
+        if err != nil {
+            return &StepError{Step: "git push", Err: fmt.Errorf("%w (the release commit and tag %s were kept locally)", err, r.Tag)}
         }
     }
     return nil
 }
 
+// pushPlan returns the remote, the remote branch and the refspecs Push
+// updates: the current branch onto its upstream (or the branch of the same
+// name) and the release tag.
+func (r Release) pushPlan(b Backend) (string, string, []string, error) {
+    local, err := b.Branch()
+    if err != nil {
+        return "", "", nil, err
+    }
+    upRemote, upBranch, err := b.Upstream()
+    if err != nil {
+        return "", "", nil, err
+    }
+    remote := r.Remote
+    if remote == "" {
+        remote = upRemote
+    }
+    if remote == "" {
+        remote = "origin"
+    }
+    branch := local
+    if remote == upRemote && upBranch != "" {
+        branch = upBranch
+    }
+    refspecs := []string{
+        fmt.Sprintf("refs/heads/%s:refs/heads/%s", local, branch),
+        fmt.Sprintf("refs/tags/%s:refs/tags/%s", r.Tag, r.Tag),
+    }
+    return remote, branch, refspecs, nil
+}
+
+// rollback undoes a partially applied release: it resets the branch to the
+// commit it pointed at before, unstages the changelog and restores its content.
+func (r Release) rollback(b Backend, file string, original []byte, existed bool, origHead string, staged bool) error {
+    var errs []error
+    if staged {
+        errs = append(errs, b.Reset(origHead, r.Changelog))
+    }
+    if existed {
+        errs = append(errs, WriteFileAtomic(file, original))
+    } else if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+        errs = append(errs, err)
+    }
+    return errors.Join(errs...)
+}
+
+// Operations lists the git commands Run executes, in order.
+func (r Release) Operations() []string {
+    commit := fmt.Sprintf("git commit -m %q", r.CommitMessage())
+    tag := fmt.Sprintf("git tag %s", r.Tag)
+    switch {
+    case r.Sign != nil:
+        commit = fmt.Sprintf("git commit -S -m %q", r.CommitMessage())
+        tag = fmt.Sprintf("git tag -s %s -F <tag message>", r.Tag)
+    case r.TagMessage != "":
+        tag = fmt.Sprintf("git tag -a %s -F <tag message>", r.Tag)
+    }
+    ops := []string{fmt.Sprintf("git add -- %s", r.Changelog), commit, tag}
+    if r.Push {
+        remote, refspecs := r.Remote, []string{"<branch>", r.Tag}
+        if b, err := r.backend(); err == nil {
+            if rem, _, specs, err := r.pushPlan(b); err == nil {
+                remote, refspecs = rem, specs
+            }
+        }
+        if remote == "" {
+            remote = "origin"
+        }
+        ops = append(ops, fmt.Sprintf("git push %s %s", remote, strings.Join(refspecs, " ")))
+    }
+    return ops
+}
 
+// Hooks returns the paths of the git hooks that the release commit would
+// trigger with the configured backend.
+func (r Release) Hooks() ([]string, error) {
+    b, err := r.backend()
+    if err != nil {
+        return nil, err
+    }
+    return b.Hooks()
+}
+
+// apply returns the changelog content after the release: the notes inserted
+// as described by InsertRelease and the link definitions updated.
+func (r Release) apply(content string) (string, error) {
+    content, err := InsertRelease(content, r.Notes, r.Version, r.Force)
+    if err != nil {
+        return "", err
+    }
+    if len(r.Links) > 0 {
+        content = UpdateLinkDefinitions(content, r.Links)
+    }
+    return content, nil
+}
+
+// Diff returns the unified diff Run would apply to the changelog file.
+func (r Release) Diff() (string, error) {
+    before, err := os.ReadFile(filepath.Join(r.RepoPath, filepath.FromSlash(r.Changelog)))
+    if err != nil && !os.IsNotExist(err) {
+        return "", err
+    }
+    after, err := r.apply(string(before))
+    if errors.Is(err, ErrVersionExists) {
+        // Reported by Preflight; there is nothing to show.
+        return "", nil
+    }
+    if err != nil {
+        return "", err
+    }
+    return UnifiedDiff(r.Changelog, string(before), after), nil
+}
+
+// DryRun writes the release plan to w without modifying the working tree or
+// any refs: the tag and commit message, the hooks that would run, the git
+// operations, the preflight results, and the diff of the changelog file.
+func (r Release) DryRun(w io.Writer) error {
+    hooks, err := r.Hooks()
+    if err != nil {
+        return err
+    }
+    diff, err := r.Diff()
+    if err != nil {
+        return err
+    }
+    fmt.Fprintf(w, "Tag: %s\n", r.Tag)
+    fmt.Fprintf(w, "Commit message: %s\n", r.CommitMessage())
+    if r.TagMessage != "" || r.Sign != nil {
+        fmt.Fprintf(w, "Tag message:\n%s\n", indentLines(strings.TrimSuffix(tagMessage(r.Tag, r.TagMessage), "\n"), "  "))
+    }
+    fmt.Fprintln(w)
+    if err := r.Preflight(); err != nil {
+        fmt.Fprintf(w, "Preflight checks failed; the release would be refused:\n%s\n\n", indentLines(err.Error(), "  "))
+    } else {
+        fmt.Fprintln(w, "Preflight checks passed")
+        fmt.Fprintln(w)
+    }
+    if len(hooks) == 0 {
+        fmt.Fprintln(w, "Hooks that would run: none")
+    } else {
+        fmt.Fprintln(w, "Hooks that would run:")
+        for _, h := range hooks {
+            fmt.Fprintf(w, "  %s\n", h)
+        }
+    }
+    fmt.Fprintln(w, "\nGit operations:")
+    for _, op := range r.Operations() {
+        fmt.Fprintf(w, "  %s\n", op)
+    }
+    fmt.Fprintln(w)
+    _, err = io.WriteString(w, diff)
+    return err
+}
+
+func indentLines(s, prefix string) string {
+    return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
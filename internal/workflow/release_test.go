@@ -1,9 +1,12 @@
 package workflow
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -48,3 +51,618 @@ func TestCommitAndTag_Smoke(t *testing.T) {
 }
 
 
+
+func TestUnifiedDiff(t *testing.T) {
+    before := "# Changelog\n\n## v1.0.0\n* a\n* b\n* c\n* d\n* e\n* f\n* g\n"
+    after := "# Changelog\n\n## v1.1.0\n* new\n\n## v1.0.0\n* a\n* b\n* c\n* d\n* e\n* f\n* G\n"
+    want := "--- a/CHANGELOG.md\n+++ b/CHANGELOG.md\n" +
+        "@@ -1,5 +1,8 @@\n # Changelog\n \n+## v1.1.0\n+* new\n+\n ## v1.0.0\n * a\n * b\n" +
+        "@@ -7,4 +10,4 @@\n * d\n * e\n * f\n-* g\n+* G\n"
+    if got := UnifiedDiff("CHANGELOG.md", before, after); got != want {
+        t.Fatalf("unexpected diff:\n got %q\nwant %q", got, want)
+    }
+    if got := UnifiedDiff("CHANGELOG.md", "same\n", "same\n"); got != "" {
+        t.Fatalf("expected empty diff, got %q", got)
+    }
+    if got := UnifiedDiff("CHANGELOG.md", "", "new\n"); got != "--- /dev/null\n+++ b/CHANGELOG.md\n@@ -0,0 +1 @@\n+new\n" {
+        t.Fatalf("unexpected diff for a new file: %q", got)
+    }
+}
+
+func TestRelease_DryRun(t *testing.T) {
+    dir := t.TempDir()
+    run := func(name string, args ...string) {
+        cmd := exec.Command(name, args...)
+        cmd.Dir = dir
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("%s %v failed: %v: %s", name, args, err, string(out))
+        }
+    }
+    run("git", "init")
+    hook := filepath.Join(dir, ".git", "hooks", "commit-msg")
+    if err := os.WriteFile(hook, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(filepath.Join(dir, "CHANGELOG.md"), []byte("## v1.0.0\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+
+    r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v1.1.0\n\n", Tag: "v1.1.0", Backend: NewExecBackend(dir, Identity{})}
+    var buf bytes.Buffer
+    if err := r.DryRun(&buf); err != nil {
+        t.Fatalf("DryRun: %v", err)
+    }
+    out := buf.String()
+    for _, want := range []string{
+        "Tag: v1.1.0",
+        "Commit message: chore(release): v1.1.0",
+        "commit-msg",
+        `git commit -m "chore(release): v1.1.0"`,
+        "git tag v1.1.0",
+        "+## v1.1.0\n",
+        " ## v1.0.0\n",
+    } {
+        if !strings.Contains(out, want) {
+            t.Fatalf("expected %q in dry-run output:\n%s", want, out)
+        }
+    }
+    b, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+    if string(b) != "## v1.0.0\n" {
+        t.Fatalf("dry run modified the changelog: %q", string(b))
+    }
+}
+
+// newReleaseRepo creates a repository on branch main with one commit and a
+// CHANGELOG.md, returning its path and a git runner.
+func newReleaseRepo(t *testing.T) (string, func(args ...string) string) {
+    t.Helper()
+    dir := t.TempDir()
+    git := func(args ...string) string {
+        t.Helper()
+        cmd := exec.Command("git", args...)
+        cmd.Dir = dir
+        out, err := cmd.CombinedOutput()
+        if err != nil {
+            t.Fatalf("git %v failed: %v: %s", args, err, string(out))
+        }
+        return strings.TrimSpace(string(out))
+    }
+    git("init", "-b", "main")
+    git("config", "user.email", "test@example.com")
+    git("config", "user.name", "Test User")
+    if err := os.WriteFile(filepath.Join(dir, "CHANGELOG.md"), []byte("## v1.0.0\n"), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    git("add", "CHANGELOG.md")
+    git("commit", "-m", "chore: init")
+    return dir, git
+}
+
+func TestExecBackend_TagExists(t *testing.T) {
+    dir, git := newReleaseRepo(t)
+    git("tag", "v1.0.0")
+    b := NewExecBackend(dir, Identity{})
+    if ok, err := b.TagExists("v1.0.0"); !ok || err != nil {
+        t.Fatalf("expected v1.0.0 to exist, got %v, %v", ok, err)
+    }
+    if ok, err := b.TagExists("v2.0.0"); ok || err != nil {
+        t.Fatalf("expected v2.0.0 to be missing without an error, got %v, %v", ok, err)
+    }
+    missing := NewExecBackend(filepath.Join(dir, "missing"), Identity{})
+    if _, err := missing.TagExists("v1.0.0"); err == nil {
+        t.Fatal("expected a git failure to be reported")
+    }
+}
+
+// backends runs fn once for each Backend implementation on the same scenario.
+func backends(t *testing.T, fn func(t *testing.T, name string)) {
+    for _, name := range []string{"native", "exec"} {
+        t.Run(name, func(t *testing.T) { fn(t, name) })
+    }
+}
+
+func newBackend(t *testing.T, name, dir string) Backend {
+    t.Helper()
+    b, err := NewBackend(name, dir, Identity{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    return b
+}
+
+// failingBackend wraps a Backend and makes one step fail after the wrapped
+// backend performed it, so rollback has real work to undo.
+type failingBackend struct {
+    Backend
+    step string
+}
+
+func (b failingBackend) Commit(message string, sign *Signing) error {
+    if err := b.Backend.Commit(message, sign); err != nil || b.step != "commit" {
+        return err
+    }
+    return errors.New("commit failed")
+}
+
+func (b failingBackend) Tag(name, message string, sign *Signing) error {
+    if err := b.Backend.Tag(name, message, sign); err != nil || b.step != "tag" {
+        return err
+    }
+    return errors.New("tag failed")
+}
+
+func TestRelease_Preflight(t *testing.T) {
+    backends(t, func(t *testing.T, name string) {
+        dir, git := newReleaseRepo(t)
+        git("tag", "v1.1.0")
+        if err := os.WriteFile(filepath.Join(dir, "CHANGELOG.md"), []byte("edited\n"), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module x\n"), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.WriteFile(filepath.Join(dir, "untracked.txt"), []byte("x\n"), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        git("add", "go.mod")
+
+        r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Tag: "v1.1.0", Branches: []string{"release/*"}, Backend: newBackend(t, name, dir)}
+        err := r.Preflight()
+        if err == nil {
+            t.Fatal("expected preflight to fail")
+        }
+        for _, want := range []string{`branch "main" is not allowed`, "tag v1.1.0 already exists", "uncommitted changes: go.mod"} {
+            if !strings.Contains(err.Error(), want) {
+                t.Fatalf("expected %q in preflight error: %v", want, err)
+            }
+        }
+        if strings.Contains(err.Error(), "CHANGELOG.md") || strings.Contains(err.Error(), "untracked.txt") {
+            t.Fatalf("changelog and untracked changes must be allowed: %v", err)
+        }
+
+        r = Release{RepoPath: dir, Changelog: "CHANGELOG.md", Tag: "v1.2.0", Branches: []string{"main"}, AllowDirty: []string{"go.mod"}, Backend: newBackend(t, name, dir)}
+        if err := r.Preflight(); err == nil || !strings.Contains(err.Error(), "staged changes would be committed with the release: go.mod") {
+            t.Fatalf("expected staged allowed file to be rejected, got %v", err)
+        }
+        git("reset", "-q", "go.mod")
+        if err := r.Preflight(); err != nil {
+            t.Fatalf("expected preflight to pass: %v", err)
+        }
+
+        git("checkout", "-q", "--detach")
+        if err := r.Preflight(); err == nil || !strings.Contains(err.Error(), "detached") {
+            t.Fatalf("expected detached HEAD error, got %v", err)
+        }
+    })
+}
+
+func TestRelease_RollbackWhenCommitFails(t *testing.T) {
+    backends(t, func(t *testing.T, name string) {
+        dir, git := newReleaseRepo(t)
+        head := git("rev-parse", "HEAD")
+
+        r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v1.1.0\n\n", Tag: "v1.1.0",
+            Backend: failingBackend{Backend: newBackend(t, name, dir), step: "commit"}}
+        err := r.Run()
+        var stepErr *StepError
+        if !errors.As(err, &stepErr) || stepErr.Step != "git commit" || stepErr.RollbackErr != nil {
+            t.Fatalf("expected rolled back git commit failure, got %v", err)
+        }
+        b, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+        if string(b) != "## v1.0.0\n" {
+            t.Fatalf("changelog not restored: %q", string(b))
+        }
+        if status := git("status", "--porcelain"); status != "" {
+            t.Fatalf("expected clean working tree after rollback, got %q", status)
+        }
+        if got := git("rev-parse", "HEAD"); got != head {
+            t.Fatalf("HEAD moved to %s", got)
+        }
+    })
+}
+
+func TestRelease_RollbackWhenHookFails(t *testing.T) {
+    dir, git := newReleaseRepo(t)
+    head := git("rev-parse", "HEAD")
+    hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+    if err := os.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+        t.Fatal(err)
+    }
+
+    r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v1.1.0\n\n", Tag: "v1.1.0", Backend: NewExecBackend(dir, Identity{})}
+    err := r.Run()
+    var stepErr *StepError
+    if !errors.As(err, &stepErr) || stepErr.Step != "git commit" || stepErr.RollbackErr != nil {
+        t.Fatalf("expected rolled back git commit failure, got %v", err)
+    }
+    if status := git("status", "--porcelain"); status != "" {
+        t.Fatalf("expected clean working tree after rollback, got %q", status)
+    }
+    if got := git("rev-parse", "HEAD"); got != head {
+        t.Fatalf("HEAD moved to %s", got)
+    }
+}
+
+func TestRelease_RollbackWhenTagFails(t *testing.T) {
+    backends(t, func(t *testing.T, name string) {
+        dir, git := newReleaseRepo(t)
+        head := git("rev-parse", "HEAD")
+
+        r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v1.1.0\n\n", Tag: "v1.1.0",
+            Backend: failingBackend{Backend: newBackend(t, name, dir), step: "tag"}}
+        err := r.Run()
+        var stepErr *StepError
+        if !errors.As(err, &stepErr) || stepErr.Step != "git tag" || stepErr.RollbackErr != nil {
+            t.Fatalf("expected rolled back git tag failure, got %v", err)
+        }
+        if got := git("rev-parse", "HEAD"); got != head {
+            t.Fatalf("release commit not rolled back: HEAD is %s, want %s", got, head)
+        }
+        if status := git("status", "--porcelain"); status != "" {
+            t.Fatalf("expected clean working tree after rollback, got %q", status)
+        }
+    })
+}
+
+func TestRelease_RollbackFirstCommit(t *testing.T) {
+    backends(t, func(t *testing.T, name string) {
+        dir := t.TempDir()
+        cmd := exec.Command("git", "init", "-q", "-b", "main", dir)
+        if out, err := cmd.CombinedOutput(); err != nil {
+            t.Fatalf("git init: %v: %s", err, out)
+        }
+        b, err := NewBackend(name, dir, Identity{Name: "Test User", Email: "test@example.com"})
+        if err != nil {
+            t.Fatal(err)
+        }
+        r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v0.1.0\n\n", Tag: "v0.1.0", Backend: failingBackend{Backend: b, step: "tag"}}
+        err = r.Run()
+        var stepErr *StepError
+        if !errors.As(err, &stepErr) || stepErr.Step != "git tag" || stepErr.RollbackErr != nil {
+            t.Fatalf("expected rolled back git tag failure, got %v", err)
+        }
+        if _, err := os.Stat(filepath.Join(dir, "CHANGELOG.md")); !os.IsNotExist(err) {
+            t.Fatalf("expected the new changelog to be removed, got %v", err)
+        }
+        if head, err := r.Backend.Head(); err != nil || head != "" {
+            t.Fatalf("expected an unborn branch after rollback, got %q, %v", head, err)
+        }
+    })
+}
+
+func TestRelease_Run(t *testing.T) {
+    backends(t, func(t *testing.T, name string) {
+        dir, git := newReleaseRepo(t)
+        r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v1.1.0\n\n", Tag: "v1.1.0", Backend: newBackend(t, name, dir)}
+        if err := r.Run(); err != nil {
+            t.Fatalf("Run: %v", err)
+        }
+        if got := git("log", "-1", "--format=%s|%an|%ae|%cn"); got != "chore(release): v1.1.0|Test User|test@example.com|Test User" {
+            t.Fatalf("unexpected release commit %q", got)
+        }
+        if got := git("tag", "--points-at", "HEAD"); got != "v1.1.0" {
+            t.Fatalf("expected tag on release commit, got %q", got)
+        }
+        if status := git("status", "--porcelain"); status != "" {
+            t.Fatalf("expected clean working tree after release, got %q", status)
+        }
+        b, _ := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+        if string(b) != "## v1.1.0\n\n## v1.0.0\n" {
+            t.Fatalf("unexpected changelog: %q", string(b))
+        }
+    })
+}
+
+func TestRelease_RunWithIdentity(t *testing.T) {
+    backends(t, func(t *testing.T, name string) {
+        dir, git := newReleaseRepo(t)
+        b, err := NewBackend(name, dir, Identity{Name: "Release Bot", Email: "bot@example.com"})
+        if err != nil {
+            t.Fatal(err)
+        }
+        r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v1.1.0\n\n", Tag: "v1.1.0", Backend: b}
+        if err := r.Run(); err != nil {
+            t.Fatalf("Run: %v", err)
+        }
+        if got := git("log", "-1", "--format=%an|%ae|%cn|%ce"); got != "Release Bot|bot@example.com|Release Bot|bot@example.com" {
+            t.Fatalf("unexpected release identity %q", got)
+        }
+    })
+}
+
+func TestRelease_AnnotatedTag(t *testing.T) {
+    backends(t, func(t *testing.T, name string) {
+        dir, git := newReleaseRepo(t)
+        r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v1.1.0\n\n### Features\n* add x\n\n", Tag: "v1.1.0",
+            TagMessage: "## v1.1.0\n\n### Features\n* add x\n\n", Backend: newBackend(t, name, dir)}
+        if err := r.Run(); err != nil {
+            t.Fatalf("Run: %v", err)
+        }
+        if got := git("cat-file", "-t", "v1.1.0"); got != "tag" {
+            t.Fatalf("expected an annotated tag, got %q", got)
+        }
+        if got := git("tag", "-l", "--format=%(contents)", "v1.1.0"); got != "## v1.1.0\n\n### Features\n* add x" {
+            t.Fatalf("unexpected tag message %q", got)
+        }
+        if got := git("tag", "-l", "--format=%(taggername) %(taggeremail)", "v1.1.0"); got != "Test User <test@example.com>" {
+            t.Fatalf("unexpected tagger %q", got)
+        }
+        if got := git("rev-parse", "v1.1.0^{commit}"); got != git("rev-parse", "HEAD") {
+            t.Fatalf("tag does not point at the release commit")
+        }
+    })
+}
+
+func TestRelease_SSHSigned(t *testing.T) {
+    if _, err := exec.LookPath("ssh-keygen"); err != nil {
+        t.Skip("ssh-keygen not available")
+    }
+    backends(t, func(t *testing.T, name string) {
+        dir, git := newReleaseRepo(t)
+        key := filepath.Join(t.TempDir(), "id_ed25519")
+        if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "test", "-f", key).CombinedOutput(); err != nil {
+            t.Fatalf("ssh-keygen: %v: %s", err, out)
+        }
+        pub, err := os.ReadFile(key + ".pub")
+        if err != nil {
+            t.Fatal(err)
+        }
+        signers := filepath.Join(t.TempDir(), "allowed_signers")
+        if err := os.WriteFile(signers, []byte("test@example.com "+string(pub)), 0o644); err != nil {
+            t.Fatal(err)
+        }
+        git("config", "gpg.ssh.allowedSignersFile", signers)
+
+        r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v1.1.0\n\n", Tag: "v1.1.0",
+            Sign: &Signing{Format: "ssh", Key: key}, Backend: newBackend(t, name, dir)}
+        if err := r.Run(); err != nil {
+            t.Fatalf("Run: %v", err)
+        }
+        git("-c", "gpg.format=ssh", "verify-commit", "HEAD")
+        git("-c", "gpg.format=ssh", "verify-tag", "v1.1.0")
+        if got := git("tag", "-l", "--format=%(contents:subject)", "v1.1.0"); got != "v1.1.0" {
+            t.Fatalf("expected the tag name as message of a signed tag without notes, got %q", got)
+        }
+    })
+}
+
+// newPushRepo returns a release repository whose main branch tracks main on
+// a local bare repository, together with the bare repository's path.
+func newPushRepo(t *testing.T) (string, func(args ...string) string, string) {
+    t.Helper()
+    dir, git := newReleaseRepo(t)
+    bare := filepath.Join(t.TempDir(), "remote.git")
+    if out, err := exec.Command("git", "init", "-q", "--bare", "-b", "main", bare).CombinedOutput(); err != nil {
+        t.Fatalf("git init --bare: %v: %s", err, out)
+    }
+    git("remote", "add", "origin", bare)
+    git("push", "-q", "-u", "origin", "main")
+    return dir, git, bare
+}
+
+func TestRelease_Push(t *testing.T) {
+    backends(t, func(t *testing.T, name string) {
+        dir, git, bare := newPushRepo(t)
+        r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v1.1.0\n\n", Tag: "v1.1.0", Push: true, Backend: newBackend(t, name, dir)}
+        if err := r.Run(); err != nil {
+            t.Fatalf("Run: %v", err)
+        }
+        head := git("rev-parse", "HEAD")
+        if got := git("--git-dir", bare, "rev-parse", "main"); got != head {
+            t.Fatalf("remote main is %s, want %s", got, head)
+        }
+        if got := git("--git-dir", bare, "rev-parse", "v1.1.0^{commit}"); got != head {
+            t.Fatalf("remote tag points at %s, want %s", got, head)
+        }
+    })
+}
+
+func TestRelease_PushPreflightBehind(t *testing.T) {
+    backends(t, func(t *testing.T, name string) {
+        dir, git, bare := newPushRepo(t)
+        other := t.TempDir()
+        git("clone", "-q", bare, other)
+        git("-C", other, "-c", "user.name=Other", "-c", "user.email=other@example.com", "commit", "-q", "--allow-empty", "-m", "fix: upstream")
+        git("-C", other, "push", "-q", "origin", "main")
+
+        r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v1.1.0\n\n", Tag: "v1.1.0", Push: true, Backend: newBackend(t, name, dir)}
+        if err := r.Preflight(); err == nil || !strings.Contains(err.Error(), "behind origin/main") {
+            t.Fatalf("expected the branch to be reported behind, got %v", err)
+        }
+        r.Push = false
+        if err := r.Preflight(); err != nil {
+            t.Fatalf("the remote must only be checked when pushing: %v", err)
+        }
+    })
+}
+
+func TestRelease_PushRejectedKeepsLocalRelease(t *testing.T) {
+    backends(t, func(t *testing.T, name string) {
+        dir, git, bare := newPushRepo(t)
+        hook := filepath.Join(bare, "hooks", "pre-receive")
+        if err := os.WriteFile(hook, []byte("#!/bin/sh\necho rejected >&2\nexit 1\n"), 0o755); err != nil {
+            t.Fatal(err)
+        }
+        before := git("--git-dir", bare, "rev-parse", "main")
+
+        r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v1.1.0\n\n", Tag: "v1.1.0", Push: true, Backend: newBackend(t, name, dir)}
+        err := r.Run()
+        var stepErr *StepError
+        if !errors.As(err, &stepErr) || stepErr.Step != "git push" {
+            t.Fatalf("expected a git push failure, got %v", err)
+        }
+        if got := git("log", "-1", "--format=%s"); got != "chore(release): v1.1.0" {
+            t.Fatalf("local release commit was not kept, HEAD is %q", got)
+        }
+        if got := git("tag", "--points-at", "HEAD"); got != "v1.1.0" {
+            t.Fatalf("local tag was not kept, got %q", got)
+        }
+        if got := git("--git-dir", bare, "rev-parse", "main"); got != before {
+            t.Fatalf("remote main moved to %s", got)
+        }
+    })
+}
+
+func TestInsertRelease(t *testing.T) {
+    notes := "## [1.1.0] - 2024-05-01\n### Features\n* x\n\n"
+    tests := []struct {
+        name    string
+        content string
+        version string
+        force   bool
+        want    string
+        wantErr bool
+    }{
+        {name: "empty file", content: "", version: "1.1.0", want: notes},
+        {
+            name:    "below title and preamble",
+            content: "# Changelog\n\nAll notable changes.\n\n## [1.0.0] - 2024-01-01\n* a\n",
+            version: "v1.1.0",
+            want:    "# Changelog\n\nAll notable changes.\n\n" + notes + "## [1.0.0] - 2024-01-01\n* a\n",
+        },
+        {
+            name:    "preamble only",
+            content: "# Changelog\nAll notable changes.",
+            version: "1.1.0",
+            want:    "# Changelog\nAll notable changes.\n\n" + notes,
+        },
+        {
+            name:    "replaces unreleased",
+            content: "# Changelog\n\n## [Unreleased]\n* wip\n\n## [1.0.0]\n* a\n",
+            version: "1.1.0",
+            want:    "# Changelog\n\n## [Unreleased]\n\n" + notes + "## [1.0.0]\n* a\n",
+        },
+        {
+            name:    "below preamble subsections",
+            content: "# Changelog\n\n## Versioning\n\nSemVer.\n\n## [1.0.0]\n* a\n",
+            version: "1.1.0",
+            want:    "# Changelog\n\n## Versioning\n\nSemVer.\n\n" + notes + "## [1.0.0]\n* a\n",
+        },
+        {
+            name:    "ignores headings in code blocks",
+            content: "# Changelog\n\n```md\n## [1.1.0]\n```\n",
+            version: "1.1.0",
+            want:    "# Changelog\n\n```md\n## [1.1.0]\n```\n\n" + notes,
+        },
+        {
+            name:    "keeps the unreleased heading of a link definition",
+            content: "# Changelog\n\n## [Unreleased]\n\n- wip\n\n[Unreleased]: https://x/compare/v1.0.0...HEAD\n",
+            version: "1.1.0",
+            want:    "# Changelog\n\n## [Unreleased]\n\n" + notes + "[Unreleased]: https://x/compare/v1.0.0...HEAD\n",
+        },
+        {
+            name:    "refuses existing version",
+            content: "# Changelog\n\n## v1.1.0 - 2024-04-01\n* old\n\n## v1.0.0\n",
+            version: "1.1.0",
+            wantErr: true,
+        },
+        {
+            name:    "force replaces existing version",
+            content: "# Changelog\n\n## [v1.1.0](https://example.com) - 2024-04-01\n* old\n\n## v1.0.0\n",
+            version: "1.1.0",
+            force:   true,
+            want:    "# Changelog\n\n" + notes + "## v1.0.0\n",
+        },
+        {
+            name:    "tag prefix",
+            content: "## api/v1.1.0\n",
+            version: "api/v1.1.0",
+            wantErr: true,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := InsertRelease(tt.content, notes, tt.version, tt.force)
+            if tt.wantErr {
+                if !errors.Is(err, ErrVersionExists) {
+                    t.Fatalf("expected ErrVersionExists, got %v", err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("InsertRelease: %v", err)
+            }
+            if got != tt.want {
+                t.Fatalf("unexpected result:\n got %q\nwant %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestRelease_PreflightExistingVersion(t *testing.T) {
+    dir, _ := newReleaseRepo(t)
+    r := Release{RepoPath: dir, Changelog: "CHANGELOG.md", Notes: "## v1.0.0\n\n", Tag: "v1.0.1", Version: "v1.0.0"}
+    if err := r.Preflight(); err == nil || !strings.Contains(err.Error(), "already has a section for v1.0.0") {
+        t.Fatalf("expected the existing section to be reported, got %v", err)
+    }
+    r.Force = true
+    if err := r.Preflight(); err != nil {
+        t.Fatalf("expected --force to allow replacing the section: %v", err)
+    }
+}
+
+func TestUpdateLinkDefinitions(t *testing.T) {
+    defs := []LinkDefinition{
+        {Label: "1.2.0", URL: "https://x/compare/v1.1.0...v1.2.0"},
+        {Label: "Unreleased", URL: "https://x/compare/v1.2.0...HEAD", ReplaceOnly: true},
+    }
+    content := "## [1.2.0]\n\n## [1.1.0]\n\n[unreleased]: https://x/compare/v1.1.0...HEAD\n[1.1.0]: https://x/compare/v1.0.0...v1.1.0\n"
+    want := "## [1.2.0]\n\n## [1.1.0]\n\n[Unreleased]: https://x/compare/v1.2.0...HEAD\n[1.2.0]: https://x/compare/v1.1.0...v1.2.0\n[1.1.0]: https://x/compare/v1.0.0...v1.1.0\n"
+    if got := UpdateLinkDefinitions(content, defs); got != want {
+        t.Fatalf("unexpected result:\n got %q\nwant %q", got, want)
+    }
+    if got := UpdateLinkDefinitions("## [1.2.0]\n", defs); got != "## [1.2.0]\n\n[1.2.0]: https://x/compare/v1.1.0...v1.2.0\n" {
+        t.Fatalf("unexpected result without definitions: %q", got)
+    }
+    if got := UpdateLinkDefinitions(want, defs); got != want {
+        t.Fatalf("expected updating twice to be stable, got %q", got)
+    }
+}
+
+func TestRelease_ApplyKeepsUnreleasedLink(t *testing.T) {
+    r := Release{
+        Notes:   "## [1.1.0] - 2024-05-01\n\n### Added\n\n- x\n",
+        Version: "1.1.0",
+        Links: []LinkDefinition{
+            {Label: "1.1.0", URL: "https://x/compare/v1.0.0...v1.1.0"},
+            {Label: "Unreleased", URL: "https://x/compare/v1.1.0...HEAD", ReplaceOnly: true},
+        },
+    }
+    content := "# Changelog\n\n## [Unreleased]\n\n- x\n\n## [1.0.0] - 2024-01-01\n\n- a\n\n" +
+        "[Unreleased]: https://x/compare/v1.0.0...HEAD\n[1.0.0]: https://x/releases/tag/v1.0.0\n"
+    want := "# Changelog\n\n## [Unreleased]\n\n## [1.1.0] - 2024-05-01\n\n### Added\n\n- x\n\n## [1.0.0] - 2024-01-01\n\n- a\n\n" +
+        "[Unreleased]: https://x/compare/v1.1.0...HEAD\n[1.1.0]: https://x/compare/v1.0.0...v1.1.0\n[1.0.0]: https://x/releases/tag/v1.0.0\n"
+    got, err := r.apply(content)
+    if err != nil {
+        t.Fatalf("apply: %v", err)
+    }
+    if got != want {
+        t.Fatalf("unexpected result:\n got %q\nwant %q", got, want)
+    }
+}
+
+func TestInstallHook(t *testing.T) {
+    dir, git := newReleaseRepo(t)
+    file, err := InstallHook(dir, "commit-msg", "#!/bin/sh\nexit 0\n", false)
+    if err != nil {
+        t.Fatalf("InstallHook: %v", err)
+    }
+    if want := filepath.Join(dir, ".git", "hooks", "commit-msg"); file != want {
+        t.Fatalf("hook written to %s, want %s", file, want)
+    }
+    if info, err := os.Stat(file); err != nil || info.Mode().Perm()&0o111 == 0 {
+        t.Fatalf("expected an executable hook: %v", err)
+    }
+    if _, err := InstallHook(dir, "commit-msg", "#!/bin/sh\n", false); err == nil {
+        t.Fatal("expected an existing hook to be kept without force")
+    }
+    if _, err := InstallHook(dir, "commit-msg", "#!/bin/sh\n", true); err != nil {
+        t.Fatalf("expected force to replace the hook: %v", err)
+    }
+
+    git("config", "core.hooksPath", ".githooks")
+    file, err = InstallHook(dir, "commit-msg", "#!/bin/sh\n", false)
+    if err != nil || file != filepath.Join(dir, ".githooks", "commit-msg") {
+        t.Fatalf("expected the hook in core.hooksPath, got %s, %v", file, err)
+    }
+}
@@ -0,0 +1,96 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	gitv5 "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+)
+
+// Signing configures the signatures of the release commit and tag. Signing
+// runs gpg, or ssh-keygen for SSH keys, like git itself does.
+type Signing struct {
+    // Format is "gpg" or "ssh"; empty uses gpg.format from the git
+    // configuration, and gpg when that is unset too.
+    Format string
+    // Key is the GPG key id or the path of the SSH private key; empty uses
+    // user.signingkey from the git configuration (or the default GPG key).
+    Key string
+}
+
+// tagMessage normalizes an annotated tag message the way go-git and
+// 'git tag --cleanup=whitespace' do: no surrounding blank lines and a single
+// trailing newline. A signed tag needs a message, so the tag name stands in
+// for an empty one.
+func tagMessage(name, message string) string {
+    message = strings.TrimSpace(message)
+    if message == "" {
+        message = name
+    }
+    return message + "\n"
+}
+
+// resolveSigning fills the format and key left empty in s from the
+// repository and global git configuration.
+func resolveSigning(repo *gitv5.Repository, s Signing) (Signing, error) {
+    if s.Format != "" && s.Key != "" {
+        return s, nil
+    }
+    local, err := repo.Config()
+    if err != nil {
+        return s, err
+    }
+    global, err := config.LoadConfig(config.GlobalScope)
+    if err != nil {
+        return s, err
+    }
+    option := func(section, key string) string {
+        if v := local.Raw.Section(section).Option(key); v != "" {
+            return v
+        }
+        return global.Raw.Section(section).Option(key)
+    }
+    if s.Format == "" {
+        s.Format = option("gpg", "format")
+    }
+    if s.Key == "" {
+        s.Key = option("user", "signingkey")
+    }
+    return s, nil
+}
+
+// execSigner implements go-git's Signer with the gpg or ssh-keygen binary.
+type execSigner struct {
+    Signing
+}
+
+func (s execSigner) Sign(message io.Reader) ([]byte, error) {
+    var cmd *exec.Cmd
+    switch s.Format {
+    case "ssh":
+        if s.Key == "" {
+            return nil, errors.New("ssh signing needs a key: set release.signing_key in .scribe.yml or user.signingkey in git config")
+        }
+        cmd = exec.Command("ssh-keygen", "-Y", "sign", "-n", "git", "-f", s.Key)
+    case "", "gpg", "openpgp":
+        args := []string{"--status-fd=2", "-bsa"}
+        if s.Key != "" {
+            args = append(args, "-u", s.Key)
+        }
+        cmd = exec.Command("gpg", args...)
+    default:
+        return nil, fmt.Errorf("unsupported signing format %q (expected gpg or ssh)", s.Format)
+    }
+    cmd.Stdin = message
+    var stderr strings.Builder
+    cmd.Stderr = &stderr
+    out, err := cmd.Output()
+    if err != nil {
+        return nil, fmt.Errorf("%s failed: %v: %s", cmd.Args[0], err, strings.TrimSpace(stderr.String()))
+    }
+    return out, nil
+}